@@ -0,0 +1,486 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrAsyncClosed is returned by the writes to an AsyncWriter that is closed.
+var ErrAsyncClosed = errors.New("log: async writer is closed")
+
+// Overflow is the policy of an AsyncWriter for writes to a full queue.
+type Overflow int
+
+const (
+	OverflowBlock      Overflow = iota // wait until the queue has room; the default
+	OverflowDropOldest                 // drop the oldest queued entry that may be dropped
+	OverflowDropNewest                 // drop the entry being written
+)
+
+// An AsyncWriter queues the entries written to it, and writes them to an
+// underlying writer from a separate goroutine, so that a slow output, like a
+// network connection, does not delay logging:
+//
+//	aw := log.NewAsyncWriter(conn, 1024, log.OverflowDropOldest)
+//	aw.SetLevelOverflow(log.LevelError, log.OverflowBlock)
+//	defer aw.Close()
+//	logger.SetOutput(aw)
+//
+// When the queue is full, writes wait for room or drop an entry, depending on
+// the Overflow policy for their level, see SetLevelOverflow. Dropped entries,
+// and entries written after Close or CloseTimeout, are counted by Dropped, and
+// reported as they are dropped, at most every 10 seconds, and in total when
+// the AsyncWriter is closed. If the underlying writer is a LevelWriter, the
+// level of each entry is passed on. If it buffers entries, like a bufio.Writer,
+// it is flushed by Flush and Close, and periodically with WithFlushInterval.
+//
+// Errors writing to the underlying writer are counted in the Health of the
+// Logger that writes to the AsyncWriter, as failed writes of this output.
+// They, and the dropped entries, are reported on the internal output of that
+// Logger, see SetInternalOutput, or on os.Stderr until a Logger uses the
+// AsyncWriter. If several do, the last one to start using it is told.
+//
+// A Logger flushes its AsyncWriter, for at most a few seconds, after writing a
+// fatal or panic entry, so that the entry is written before the program exits
+// or panics.
+type AsyncWriter struct {
+	w       io.Writer
+	wmu     sync.Mutex // held while writing to or flushing w
+	dirty   bool       // whether w was written to since it was flushed
+	every   time.Duration
+	ticker  *time.Ticker
+	mu      sync.Mutex
+	room    sync.Cond // signaled when an entry is taken from the queue
+	queue   ring
+	policy  Overflow // for entries written without a level
+	levels  [MaxLevel + 1]Overflow
+	closed  bool
+	busy    int           // number of queued entries and entries being written
+	idle    chan struct{} // closed when busy drops to 0
+	wake    chan struct{} // wakes run when entries are queued or on close
+	once    sync.Once
+	dropped atomic.Int64
+	done    chan struct{}
+
+	// Drop notices, guarded by mu.
+	noticeEvery time.Duration // minimum time between notices
+	since       time.Time     // time of the last notice, or of creation
+	next        time.Time     // time from which a notice may be written
+	unreported  int64         // entries dropped since the last notice
+
+	health health // of the writes to w
+	logger atomic.Pointer[Logger]
+}
+
+type asyncEntry struct {
+	level   int
+	leveled bool
+	p       []byte
+}
+
+// ring is a fixed-size FIFO queue of entries.
+type ring struct {
+	buf  []asyncEntry
+	head int
+	n    int
+}
+
+func (r *ring) full() bool { return r.n == len(r.buf) }
+
+func (r *ring) push(e asyncEntry) {
+	r.buf[(r.head+r.n)%len(r.buf)] = e
+	r.n++
+}
+
+func (r *ring) pop() asyncEntry {
+	e := r.buf[r.head]
+	r.buf[r.head] = asyncEntry{}
+	r.head = (r.head + 1) % len(r.buf)
+	r.n--
+	return e
+}
+
+// at returns the i-th oldest entry.
+func (r *ring) at(i int) *asyncEntry {
+	return &r.buf[(r.head+i)%len(r.buf)]
+}
+
+// remove removes the i-th oldest entry, keeping the order of the others.
+func (r *ring) remove(i int) {
+	for ; i < r.n-1; i++ {
+		*r.at(i) = *r.at(i + 1)
+	}
+	*r.at(i) = asyncEntry{}
+	r.n--
+}
+
+// An AsyncOption configures an AsyncWriter created by NewAsyncWriter.
+type AsyncOption func(*AsyncWriter)
+
+// WithFlushInterval flushes the underlying writer every d if entries were
+// written to it since it was last flushed, so that the last entries of a quiet
+// program do not linger in its buffer. The underlying writer is flushed if it
+// has a Flush method returning an error, like a bufio.Writer; an explicit Flush
+// restarts the interval. The default, 0, only flushes it on Flush and Close.
+func WithFlushInterval(d time.Duration) AsyncOption {
+	return func(a *AsyncWriter) { a.every = d }
+}
+
+// writeFlusher is an underlying writer that buffers entries.
+type writeFlusher interface {
+	Flush() error
+}
+
+// defaultCloseTimeout is how long Close waits for the queued entries.
+const defaultCloseTimeout = 5 * time.Second
+
+// dropNoticeInterval is the minimum time between the notices of dropped
+// entries.
+const dropNoticeInterval = 10 * time.Second
+
+// NewAsyncWriter returns an AsyncWriter that writes to w, with room for queue
+// entries, and starts its goroutine. The queue has room for at least one
+// entry. The policy applies to all levels until changed with
+// SetLevelOverflow. It must be closed with Close or CloseTimeout to stop the
+// goroutine.
+func NewAsyncWriter(w io.Writer, queue int, policy Overflow, opts ...AsyncOption) *AsyncWriter {
+	if queue < 1 {
+		queue = 1
+	}
+	a := &AsyncWriter{
+		w:           w,
+		queue:       ring{buf: make([]asyncEntry, queue)},
+		policy:      policy,
+		wake:        make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		noticeEvery: dropNoticeInterval,
+		since:       time.Now(),
+	}
+	a.room.L = &a.mu
+	for i := range a.levels {
+		a.levels[i] = policy
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.every > 0 {
+		a.ticker = time.NewTicker(a.every)
+	}
+	go a.run()
+	return a
+}
+
+// SetLevelOverflow sets the policy for the entries at level, so that, for
+// example, debug entries are dropped under load while errors never are. With
+// OverflowDropOldest, the oldest queued entry whose own policy allows dropping
+// it is dropped; if there is none, the write waits for room. It panics if level
+// is not between 0 and MaxLevel.
+func (a *AsyncWriter) SetLevelOverflow(level int, policy Overflow) {
+	if level < 0 || level > MaxLevel {
+		panic("log: level out of range")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.levels[level] = policy
+}
+
+// LevelOverflow returns the policy for the entries at level.
+func (a *AsyncWriter) LevelOverflow(level int) Overflow {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.overflow(asyncEntry{level: level, leveled: true})
+}
+
+// overflow returns the policy for e. It must be called with a.mu held.
+func (a *AsyncWriter) overflow(e asyncEntry) Overflow {
+	if e.leveled && e.level >= 0 && e.level <= MaxLevel {
+		return a.levels[e.level]
+	}
+	return a.policy
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+	var tick <-chan time.Time
+	if a.ticker != nil {
+		defer a.ticker.Stop()
+		tick = a.ticker.C
+	}
+	for {
+		a.mu.Lock()
+		for a.queue.n == 0 {
+			if a.closed {
+				a.mu.Unlock()
+				a.flushWriter()
+				if n := a.dropped.Load(); n > 0 {
+					a.reportf("async writer closed after dropping %d log entries", n)
+				}
+				return
+			}
+			a.mu.Unlock()
+			select {
+			case <-a.wake:
+			case <-tick:
+				a.flushWriter()
+			}
+			a.mu.Lock()
+		}
+		e := a.queue.pop()
+		a.room.Signal()
+		a.mu.Unlock()
+
+		a.write(e)
+		a.mu.Lock()
+		a.release()
+		a.mu.Unlock()
+		select {
+		case <-tick:
+			a.flushWriter()
+		default:
+		}
+	}
+}
+
+// write writes e to the underlying writer.
+func (a *AsyncWriter) write(e asyncEntry) {
+	a.wmu.Lock()
+	defer a.wmu.Unlock()
+	var err error
+	if lw, ok := a.w.(LevelWriter); ok && e.leveled {
+		_, err = lw.WriteLevel(e.level, e.p)
+	} else {
+		_, err = a.w.Write(e.p)
+	}
+	a.dirty = true
+	a.health.record(err)
+	if err != nil {
+		a.reportf("async write failed: %v", err)
+	}
+}
+
+// flushWriter flushes the underlying writer if it buffers entries and was
+// written to since it was last flushed.
+func (a *AsyncWriter) flushWriter() {
+	f, ok := a.w.(writeFlusher)
+	if !ok {
+		return
+	}
+	a.wmu.Lock()
+	defer a.wmu.Unlock()
+	if !a.dirty {
+		return
+	}
+	a.dirty = false
+	err := f.Flush()
+	a.health.record(err)
+	if err != nil {
+		a.reportf("async flush failed: %v", err)
+	}
+}
+
+// reportf reports a problem of a on the internal output of the Logger using
+// it, or on os.Stderr.
+func (a *AsyncWriter) reportf(format string, v ...interface{}) {
+	if l := a.logger.Load(); l != nil {
+		l.internalf(format, v...)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "log: "+format+"\n", v...)
+}
+
+// attach records l as the Logger using a, and attaches the underlying writer
+// to l too, see attacher.
+func (a *AsyncWriter) attach(l *Logger) {
+	a.logger.Store(l)
+	if w, ok := a.w.(attacher); ok {
+		w.attach(l)
+	}
+}
+
+// writeHealth returns the health of the writes to the underlying writer.
+func (a *AsyncWriter) writeHealth() *health {
+	return &a.health
+}
+
+// drop counts a dropped entry, and returns the notice to report, if one is
+// due. It must be called with a.mu held.
+func (a *AsyncWriter) drop() (notice string) {
+	a.dropped.Add(1)
+	a.unreported++
+	now := time.Now()
+	if now.Before(a.next) {
+		return ""
+	}
+	d := now.Sub(a.since).Round(time.Millisecond)
+	if d < time.Millisecond {
+		d = time.Millisecond
+	}
+	notice = fmt.Sprintf("dropped %d log entries in the last %v", a.unreported, d)
+	a.since = now
+	a.next = now.Add(a.noticeEvery)
+	a.unreported = 0
+	return notice
+}
+
+// release counts an entry that was written or dropped. It must be called with
+// a.mu held.
+func (a *AsyncWriter) release() {
+	a.busy--
+	if a.busy == 0 {
+		close(a.idle)
+	}
+}
+
+// Write queues a copy of p.
+func (a *AsyncWriter) Write(p []byte) (n int, err error) {
+	return a.enqueue(asyncEntry{p: p})
+}
+
+// WriteLevel queues a copy of p, to be written at the given level if the
+// underlying writer is a LevelWriter.
+func (a *AsyncWriter) WriteLevel(level int, p []byte) (n int, err error) {
+	return a.enqueue(asyncEntry{level: level, leveled: true, p: p})
+}
+
+func (a *AsyncWriter) enqueue(e asyncEntry) (int, error) {
+	n, notice, err := a.push(e)
+	if notice != "" {
+		a.reportf("%s", notice)
+	}
+	return n, err
+}
+
+// push queues a copy of e, and returns the notice of dropped entries to
+// report, if one is due.
+func (a *AsyncWriter) push(e asyncEntry) (n int, notice string, err error) {
+	n = len(e.p)
+	e.p = append([]byte(nil), e.p...)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for !a.closed && a.queue.full() {
+		policy := a.overflow(e)
+		if policy == OverflowDropNewest {
+			return n, a.drop(), nil
+		}
+		if policy == OverflowDropOldest && a.dropOldest(&notice) {
+			break
+		}
+		a.room.Wait()
+	}
+	if a.closed {
+		return 0, a.drop(), ErrAsyncClosed
+	}
+	a.queue.push(e)
+	if a.busy == 0 {
+		a.idle = make(chan struct{})
+	}
+	a.busy++
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+	return n, notice, nil
+}
+
+// dropOldest drops the oldest queued entry whose policy allows dropping it,
+// and reports whether there was one. It sets *notice as drop does. It must be
+// called with a.mu held.
+func (a *AsyncWriter) dropOldest(notice *string) bool {
+	for i := 0; i < a.queue.n; i++ {
+		if a.overflow(*a.queue.at(i)) != OverflowBlock {
+			a.queue.remove(i)
+			a.release()
+			*notice = a.drop()
+			return true
+		}
+	}
+	return false
+}
+
+// Dropped returns the number of entries that were dropped because the queue
+// was full, or because they were written after Close or CloseTimeout.
+func (a *AsyncWriter) Dropped() int64 {
+	return a.dropped.Load()
+}
+
+// Flush waits for the queued entries to be written, for at most timeout, and
+// flushes the underlying writer if it buffers entries. It returns an error if
+// they were not all written in time.
+func (a *AsyncWriter) Flush(timeout time.Duration) error {
+	a.mu.Lock()
+	idle := a.idle
+	if a.busy == 0 {
+		idle = nil
+	}
+	a.mu.Unlock()
+	if idle != nil {
+		select {
+		case <-idle:
+		case <-time.After(timeout):
+			return fmt.Errorf("log: async flush timed out with %d entries queued", a.queued())
+		}
+	}
+	a.flushWriter()
+	if a.ticker != nil {
+		a.ticker.Reset(a.every)
+	}
+	return nil
+}
+
+// queued returns the number of queued entries.
+func (a *AsyncWriter) queued() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.queue.n
+}
+
+// Close is like CloseTimeout, with a timeout of 5 seconds.
+func (a *AsyncWriter) Close() error {
+	return a.CloseTimeout(defaultCloseTimeout)
+}
+
+// CloseTimeout stops accepting entries and waits for the queued entries to be
+// written, for at most timeout. Later writes, and writes waiting for room in
+// the queue, fail with ErrAsyncClosed. The underlying writer is flushed if it
+// buffers entries, but not closed. It returns an error if the queued entries
+// were not all written in time; they are then still written in the
+// background.
+func (a *AsyncWriter) CloseTimeout(timeout time.Duration) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	a.once.Do(func() {
+		a.mu.Lock()
+		a.closed = true
+		a.room.Broadcast()
+		a.mu.Unlock()
+		select {
+		case a.wake <- struct{}{}:
+		default:
+		}
+	})
+	select {
+	case <-a.done:
+		return nil
+	case <-deadline.C:
+		return fmt.Errorf("log: async close timed out with %d entries queued", a.queued())
+	}
+}
+
+// flusher is an output that buffers entries, like an AsyncWriter.
+type flusher interface {
+	Flush(timeout time.Duration) error
+}
+
+// flushOutput flushes w, for at most a few seconds, if it buffers entries.
+func (l *Logger) flushOutput(w io.Writer) {
+	if f, ok := w.(flusher); ok {
+		if err := f.Flush(2 * time.Second); err != nil {
+			l.internalf("%v", err)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var _ io.Closer = (*AsyncWriter)(nil)
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use, and that
+// optionally sleeps before each write.
+type syncBuffer struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	delay time.Duration
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	time.Sleep(b.delay)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// blockingWriter blocks in Write until release is closed.
+type blockingWriter struct {
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+func TestAsyncWriterShutdown(t *testing.T) {
+	for _, policy := range []Overflow{OverflowBlock, OverflowDropOldest} {
+		out := &syncBuffer{delay: 10 * time.Microsecond}
+		aw := NewAsyncWriter(out, 16, policy)
+		l := New(aw, "", 0)
+		l.SetInternalOutput(io.Discard)
+
+		// Close while the producers are still logging: every entry must
+		// be either written or counted as dropped.
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 200; i++ {
+					l.Error("entry")
+					if i%50 == 0 {
+						aw.Flush(time.Second)
+					}
+				}
+			}()
+		}
+		time.Sleep(5 * time.Millisecond)
+		if err := aw.Close(); err != nil {
+			t.Fatalf("policy %d: Close: %v", policy, err)
+		}
+		wg.Wait()
+		if n := int64(strings.Count(out.String(), "entry")); n+aw.Dropped() != 1600 {
+			t.Errorf("policy %d: %d entries written and %d dropped, want 1600 in total", policy, n, aw.Dropped())
+		}
+		if _, err := aw.Write([]byte("late\n")); err != ErrAsyncClosed {
+			t.Errorf("policy %d: Write after Close returned %v, want ErrAsyncClosed", policy, err)
+		}
+	}
+}
+
+func TestAsyncWriterCloseTimeout(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	defer close(w.release)
+	aw := NewAsyncWriter(w, 1, OverflowBlock)
+	aw.Write([]byte("in flight\n"))
+	aw.Write([]byte("queued\n"))
+	blocked := make(chan error)
+	go func() {
+		// The queue is full, so this waits for room until Close.
+		_, err := aw.Write([]byte("waiting\n"))
+		blocked <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	start := time.Now()
+	if err := aw.CloseTimeout(50 * time.Millisecond); err == nil {
+		t.Error("CloseTimeout returned no error with a write in flight")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("CloseTimeout took %v with a timeout of 50ms", d)
+	}
+	if err := <-blocked; err != ErrAsyncClosed {
+		t.Errorf("waiting Write returned %v, want ErrAsyncClosed", err)
+	}
+}
+
+func TestAsyncWriterZeroQueue(t *testing.T) {
+	out := &syncBuffer{}
+	aw := NewAsyncWriter(out, 0, OverflowDropOldest)
+	for i := 0; i < 100; i++ {
+		aw.Write([]byte("entry\n"))
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := int64(strings.Count(out.String(), "entry")); n == 0 || n+aw.Dropped() != 100 {
+		t.Errorf("%d entries written and %d dropped, want 100 in total", n, aw.Dropped())
+	}
+}
+
+func TestAsyncWriterPanicFlush(t *testing.T) {
+	out := &syncBuffer{delay: time.Millisecond}
+	aw := NewAsyncWriter(out, 16, OverflowBlock)
+	defer aw.Close()
+	l := New(aw, "", 0)
+	func() {
+		defer func() { recover() }()
+		l.Panic("last words")
+	}()
+	if !strings.Contains(out.String(), "last words") {
+		t.Errorf("panic entry was not flushed: %q", out.String())
+	}
+}
+
+// stepWriter records the entries written to it, and blocks in Write until
+// release is sent to or closed.
+type stepWriter struct {
+	writing chan string
+	release chan struct{}
+}
+
+func newStepWriter() *stepWriter {
+	return &stepWriter{writing: make(chan string, 16), release: make(chan struct{})}
+}
+
+func (w *stepWriter) Write(p []byte) (int, error) {
+	w.writing <- strings.TrimSuffix(string(p), "\n")
+	<-w.release
+	return len(p), nil
+}
+
+// finish lets all writes through, closes aw and returns the entries written.
+func (w *stepWriter) finish(t *testing.T, aw *AsyncWriter) string {
+	t.Helper()
+	close(w.release)
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	close(w.writing)
+	var got []string
+	for s := range w.writing {
+		got = append(got, s)
+	}
+	return strings.Join(got, ",")
+}
+
+func TestAsyncWriterOverflow(t *testing.T) {
+	for _, c := range []struct {
+		policy  Overflow
+		want    string
+		dropped int64
+	}{
+		{OverflowBlock, "1,2,3,4", 0},
+		{OverflowDropOldest, "1,3,4", 1},
+		{OverflowDropNewest, "1,2,3", 1},
+	} {
+		w := newStepWriter()
+		aw := NewAsyncWriter(w, 2, c.policy)
+		aw.Write([]byte("1\n"))
+		<-w.writing // 1 is being written, so 2 and 3 fill the queue
+		aw.Write([]byte("2\n"))
+		aw.Write([]byte("3\n"))
+		done := make(chan struct{})
+		go func() {
+			aw.Write([]byte("4\n"))
+			close(done)
+		}()
+		if c.policy == OverflowBlock {
+			select {
+			case <-done:
+				t.Errorf("policy %d: Write to a full queue did not block", c.policy)
+			case <-time.After(10 * time.Millisecond):
+			}
+			w.release <- struct{}{}
+		}
+		<-done
+
+		got := "1," + w.finish(t, aw)
+		if got != c.want || aw.Dropped() != c.dropped {
+			t.Errorf("policy %d: wrote %s and dropped %d, want %s and %d", c.policy, got, aw.Dropped(), c.want, c.dropped)
+		}
+	}
+}
+
+func TestAsyncWriterLevelOverflow(t *testing.T) {
+	w := newStepWriter()
+	aw := NewAsyncWriter(w, 2, OverflowDropOldest)
+	aw.SetLevelOverflow(LevelError, OverflowBlock)
+	if p := aw.LevelOverflow(LevelError); p != OverflowBlock {
+		t.Errorf("LevelOverflow(LevelError) = %d, want OverflowBlock", p)
+	}
+	aw.WriteLevel(LevelInfo, []byte("info 1\n"))
+	<-w.writing
+	aw.WriteLevel(LevelError, []byte("error 2\n"))
+	aw.WriteLevel(LevelDebug, []byte("debug 3\n"))
+	// The oldest entry that may be dropped is debug 3, not error 2.
+	aw.WriteLevel(LevelInfo, []byte("info 4\n"))
+
+	w.release <- struct{}{}
+	<-w.writing // error 2
+	w.release <- struct{}{}
+	<-w.writing // info 4
+
+	// Only errors are queued now, so even a debug entry waits for room.
+	aw.WriteLevel(LevelError, []byte("error 5\n"))
+	aw.WriteLevel(LevelError, []byte("error 6\n"))
+	done := make(chan struct{})
+	go func() {
+		aw.WriteLevel(LevelDebug, []byte("debug 7\n"))
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Error("Write did not wait with only errors queued")
+	case <-time.After(10 * time.Millisecond):
+	}
+	w.release <- struct{}{}
+	<-done
+
+	if got, want := w.finish(t, aw), "error 5,error 6,debug 7"; got != want {
+		t.Errorf("wrote %s after info 4, want %s", got, want)
+	}
+	if aw.Dropped() != 1 {
+		t.Errorf("Dropped = %d, want 1", aw.Dropped())
+	}
+}
+
+func TestAsyncWriterFlushInterval(t *testing.T) {
+	for _, every := range []time.Duration{0, 5 * time.Millisecond} {
+		out := &syncBuffer{}
+		aw := NewAsyncWriter(bufio.NewWriter(out), 16, OverflowBlock, WithFlushInterval(every))
+		aw.Write([]byte("quiet\n"))
+		time.Sleep(50 * time.Millisecond)
+		if got, want := out.String() != "", every > 0; got != want {
+			t.Errorf("interval %v: entry flushed = %t, want %t", every, got, want)
+		}
+
+		aw.Write([]byte("flushed\n"))
+		if err := aw.Flush(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out.String(), "flushed") {
+			t.Errorf("interval %v: entry not flushed by Flush", every)
+		}
+
+		aw.Write([]byte("closed\n"))
+		if err := aw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out.String(), "closed") {
+			t.Errorf("interval %v: entry not flushed by Close", every)
+		}
+	}
+}
+
+func TestAsyncWriterDropNotice(t *testing.T) {
+	w := newStepWriter()
+	aw := NewAsyncWriter(w, 1, OverflowDropNewest)
+	l := New(aw, "", 0)
+	var diag syncBuffer
+	l.SetInternalOutput(&diag)
+
+	// One entry is being written and one is queued, the others are dropped.
+	l.Error("entry")
+	<-w.writing
+	for i := 0; i < 4; i++ {
+		l.Error("entry")
+	}
+	if s := l.Stats(); s.DroppedEntries != 3 || aw.Dropped() != 3 {
+		t.Errorf("Stats().DroppedEntries = %d, Dropped = %d, want 3", s.DroppedEntries, aw.Dropped())
+	}
+	notices := strings.Split(strings.TrimSuffix(diag.String(), "\n"), "\n")
+	if len(notices) != 1 || !strings.HasPrefix(notices[0], "log: dropped 1 log entries in the last ") {
+		t.Errorf("notices after the first drops = %q, want one for the first drop", notices)
+	}
+
+	// The next notice covers the entries dropped since the last one.
+	aw.mu.Lock()
+	aw.next = time.Time{}
+	aw.mu.Unlock()
+	l.Error("entry")
+
+	w.finish(t, aw)
+	notices = strings.Split(strings.TrimSuffix(diag.String(), "\n"), "\n")
+	if len(notices) != 3 || !strings.HasPrefix(notices[1], "log: dropped 3 log entries in the last ") || notices[2] != "log: async writer closed after dropping 4 log entries" {
+		t.Errorf("notices = %q", notices)
+	}
+}
+
+func TestAsyncWriterHealth(t *testing.T) {
+	aw := NewAsyncWriter(failingWriter{}, 4, OverflowBlock)
+	defer aw.Close()
+	l := New(&bytes.Buffer{}, "", 0)
+	l.AddOutput(aw, 0)
+	var diag syncBuffer
+	l.SetInternalOutput(&diag)
+
+	l.Error("lost")
+	aw.Flush(time.Second)
+	s := l.Health()
+	if s.FailedWrites != 1 || s.LastError == nil || s.FailingSince.IsZero() {
+		t.Errorf("Health = %+v, want the failed write of the AsyncWriter", s)
+	}
+	if o := s.Outputs[1]; o.Output != aw || o.FailedWrites != 1 || o.LastError == nil {
+		t.Errorf("health of the AsyncWriter = %+v", o)
+	}
+	if !strings.Contains(diag.String(), "log: async write failed: ") {
+		t.Errorf("internal output = %q, want the failed write", diag.String())
+	}
+
+	l.ResetHealth()
+	if s := l.Health(); s.FailedWrites != 0 || s.Outputs[1].FailedWrites != 0 {
+		t.Errorf("Health after ResetHealth = %+v", s)
+	}
+}
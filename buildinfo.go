@@ -0,0 +1,48 @@
+package log
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// BuildFields returns information about the running binary as fields: the main
+// module path and version, the VCS revision, time and modified flag, and the Go
+// version. Fields that are not available, as in tests or binaries built without
+// module support, are left out.
+func BuildFields() []Field {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return []Field{{"go", runtime.Version()}}
+	}
+	var f []Field
+	if info.Main.Path != "" {
+		f = append(f, Field{"module", info.Main.Path})
+	}
+	if info.Main.Version != "" {
+		f = append(f, Field{"version", info.Main.Version})
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision", "vcs.time", "vcs.modified":
+			f = append(f, Field{s.Key, s.Value})
+		}
+	}
+	return append(f, Field{"go", info.GoVersion})
+}
+
+// LogBuildInfo logs the fields returned by BuildFields at the given level.
+func (l *Logger) LogBuildInfo(level int) {
+	f := BuildFields()
+	defer l.unlock(l.lock())
+	if l.enabled(level) {
+		l.format(level, string(appendFieldList([]byte("build info:"), f, l)))
+	}
+}
+
+func LogBuildInfo(level int) {
+	f := BuildFields()
+	defer std.unlock(std.lock())
+	if std.enabled(level) {
+		std.format(level, string(appendFieldList([]byte("build info:"), f, std)))
+	}
+}
@@ -0,0 +1,55 @@
+package log
+
+import "time"
+
+// burst detects bursts of error entries: n or more within a sliding window.
+type burst struct {
+	n      int
+	window time.Duration
+	fn     func(count int)
+	times  []time.Time // ring of the times of the last n error entries
+	pos    int
+	fired  bool
+	last   time.Time
+}
+
+// OnErrorBurst arranges for fn to be called when n or more entries at error
+// level or above are logged within window. After firing, fn is not called
+// again until the rate has dropped below the threshold, and never more than
+// once per window. fn runs in its own goroutine, so it may use the logger. A
+// nil fn removes the burst detection.
+func (l *Logger) OnErrorBurst(n int, window time.Duration, fn func(count int)) {
+	defer l.unlock(l.lock())
+	if fn == nil || n <= 0 {
+		l.burst = nil
+		return
+	}
+	l.burst = &burst{
+		n:      n,
+		window: window,
+		fn:     fn,
+		times:  make([]time.Time, n),
+	}
+}
+
+func OnErrorBurst(n int, window time.Duration, fn func(count int)) {
+	std.OnErrorBurst(n, window, fn)
+}
+
+// observe records an error entry logged at time t.
+func (b *burst) observe(t time.Time) {
+	b.times[b.pos] = t
+	b.pos = (b.pos + 1) % b.n
+	// The oldest of the last n entries is the one that will be overwritten next.
+	oldest := b.times[b.pos]
+	if oldest.IsZero() || t.Sub(oldest) >= b.window {
+		b.fired = false
+		return
+	}
+	if b.fired || (!b.last.IsZero() && t.Sub(b.last) < b.window) {
+		return
+	}
+	b.fired = true
+	b.last = t
+	go b.fn(b.n)
+}
@@ -0,0 +1,65 @@
+package log
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestOnErrorBurst(t *testing.T) {
+	l := New(io.Discard, "", 0)
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+	fired := make(chan int, 10)
+	l.OnErrorBurst(3, time.Minute, func(count int) { fired <- count })
+
+	expect := func(step string, want bool) {
+		t.Helper()
+		select {
+		case n := <-fired:
+			if !want {
+				t.Errorf("%s: fired with %d", step, n)
+			} else if n != 3 {
+				t.Errorf("%s: fired with %d, want 3", step, n)
+			}
+		case <-time.After(50 * time.Millisecond):
+			if want {
+				t.Errorf("%s: not fired", step)
+			}
+		}
+	}
+
+	// Two errors and a warning are not a burst.
+	l.Error("1")
+	l.Warn("warning")
+	now = now.Add(30 * time.Second)
+	l.Error("2")
+	expect("below threshold", false)
+
+	// Three errors within the window are.
+	now = now.Add(20 * time.Second)
+	l.Error("3")
+	expect("burst", true)
+
+	// The burst continues, but it fires only once.
+	for i := 0; i < 5; i++ {
+		now = now.Add(time.Second)
+		l.Error("more")
+	}
+	expect("continued burst", false)
+
+	// The rate drops below the threshold, and a new burst, a window after the
+	// first, fires again.
+	now = now.Add(2 * time.Minute)
+	l.Error("quiet")
+	expect("after the burst", false)
+	l.Error("a")
+	l.Error("b")
+	expect("second burst", true)
+
+	l.OnErrorBurst(0, 0, nil)
+	for i := 0; i < 5; i++ {
+		l.Error("removed")
+	}
+	expect("removed", false)
+}
@@ -0,0 +1,24 @@
+package log
+
+import golog "log"
+
+// CaptureStandardLog redirects the output of the standard library's log
+// package to l, logging each line at the given level. The flags and prefix of
+// the standard logger are cleared, so that entries are not timestamped twice.
+// If classify is true, lines containing "error" or "warn" are logged at error
+// or warn level instead, if that is more severe than level. The returned
+// function restores the previous output, flags and prefix of the standard
+// logger.
+func CaptureStandardLog(l *Logger, level int, classify bool) (restore func()) {
+	out, flags, prefix := golog.Writer(), golog.Flags(), golog.Prefix()
+	w := &lineWriter{l: l, level: level, classify: classify}
+	golog.SetOutput(w)
+	golog.SetFlags(0)
+	golog.SetPrefix("")
+	return func() {
+		golog.SetOutput(out)
+		golog.SetFlags(flags)
+		golog.SetPrefix(prefix)
+		w.Close()
+	}
+}
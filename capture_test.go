@@ -0,0 +1,44 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	golog "log"
+	"runtime"
+	"testing"
+)
+
+func TestCaptureStandardLog(t *testing.T) {
+	out, flags, prefix := golog.Writer(), golog.Flags(), golog.Prefix()
+	defer func() {
+		golog.SetOutput(out)
+		golog.SetFlags(flags)
+		golog.SetPrefix(prefix)
+	}()
+	var orig bytes.Buffer
+	golog.SetOutput(&orig)
+	golog.SetFlags(golog.Lshortfile)
+	golog.SetPrefix("std: ")
+
+	var buf bytes.Buffer
+	l := New(&buf, "", Llabel)
+	l.SetLevel(LevelDebug)
+
+	_, _, line, _ := runtime.Caller(0)
+	golog.Print("before")
+	restore := CaptureStandardLog(l, LevelInfo, true)
+	golog.Print("during")
+	golog.Printf("an Error occurred\nwarning: second line")
+	golog.Print("debug output")
+	restore()
+	golog.Print("after")
+
+	want := "[INFO ] during\n[ERROR] an Error occurred\n[WARN ] warning: second line\n[INFO ] debug output\n"
+	if got := buf.String(); got != want {
+		t.Errorf("captured %q, want %q", got, want)
+	}
+	want = fmt.Sprintf("std: capture_test.go:%d: before\nstd: capture_test.go:%d: after\n", line+1, line+7)
+	if got := orig.String(); got != want {
+		t.Errorf("standard logger wrote %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// Hash chained output appends a tag to every entry:
+//
+//	<entry> #mac=<first 8 bytes of the MAC, in hex>
+//
+// where the MAC is the HMAC-SHA256 of the previous entry's MAC followed by the
+// entry. Every so many entries a checkpoint line carries the full MAC:
+//
+//	#checkpoint <number of entries> <MAC, in hex>
+const (
+	chainTag        = " #mac="
+	chainTagLen     = 8
+	chainCheckpoint = "#checkpoint "
+)
+
+type chainWriter struct {
+	mu         sync.Mutex
+	w          io.Writer
+	mac        hash.Hash
+	prev       []byte
+	seq        int
+	checkpoint int
+	buf        []byte
+}
+
+// NewChainWriter returns a writer for tamper-evident audit logs. It appends to
+// the data of each call to Write, an entry, a truncated HMAC computed over the
+// entry and the MAC of the previous entry, so that modifying, inserting or
+// removing an entry breaks the chain. After every checkpoint entries, if
+// checkpoint is positive, it writes a line with the full chain state. Use
+// VerifyChain to verify the output.
+func NewChainWriter(w io.Writer, key []byte, checkpoint int) io.Writer {
+	mac := hmac.New(sha256.New, key)
+	return &chainWriter{
+		w:          w,
+		mac:        mac,
+		prev:       make([]byte, mac.Size()),
+		checkpoint: checkpoint,
+	}
+}
+
+func (c *chainWriter) Write(p []byte) (n int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := bytes.TrimSuffix(p, []byte{'\n'})
+	c.prev = chainMAC(c.mac, c.prev, entry)
+	c.seq++
+
+	b := append(c.buf[:0], entry...)
+	b = append(b, chainTag...)
+	b = hex.AppendEncode(b, c.prev[:chainTagLen])
+	b = append(b, '\n')
+	if c.checkpoint > 0 && c.seq%c.checkpoint == 0 {
+		b = append(b, chainCheckpoint...)
+		b = strconv.AppendInt(b, int64(c.seq), 10)
+		b = append(b, ' ')
+		b = hex.AppendEncode(b, c.prev)
+		b = append(b, '\n')
+	}
+	c.buf = b
+	if _, err = c.w.Write(b); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func chainMAC(mac hash.Hash, prev, entry []byte) []byte {
+	mac.Reset()
+	mac.Write(prev)
+	mac.Write(entry)
+	return mac.Sum(prev[:0])
+}
+
+// A ChainError reports where the hash chain of an audit log is broken.
+type ChainError struct {
+	Line int // line at which the first broken entry starts
+}
+
+func (e *ChainError) Error() string {
+	return "log: hash chain broken at line " + strconv.Itoa(e.Line)
+}
+
+// VerifyChain verifies the output of a writer returned by NewChainWriter with
+// the given key. If the chain is broken it returns a *ChainError identifying
+// the first entry that does not match. Entries spanning several lines are
+// supported.
+func VerifyChain(r io.Reader, key []byte) error {
+	mac := hmac.New(sha256.New, key)
+	prev := make([]byte, mac.Size())
+	seq := 0
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	var entry []byte
+	line, start := 0, 1
+	for sc.Scan() {
+		line++
+		text := sc.Bytes()
+		i := bytes.LastIndex(text, []byte(chainTag))
+		if i < 0 || len(text)-i-len(chainTag) != 2*chainTagLen {
+			// A checkpoint can only be told from an entry that looks like
+			// one by its MAC, which cannot be forged without the key. A
+			// line that does not match is part of the next entry, which
+			// then breaks the chain.
+			if len(entry) == 0 && bytes.HasPrefix(text, []byte(chainCheckpoint)) &&
+				string(text) == fmt.Sprintf("%s%d %x", chainCheckpoint, seq, prev) {
+				start = line + 1
+				continue
+			}
+			// Not the last line of the entry.
+			entry = append(append(entry, text...), '\n')
+			continue
+		}
+		entry = append(entry, text[:i]...)
+		prev = chainMAC(mac, prev, entry)
+		seq++
+		if !bytes.Equal(hex.AppendEncode(nil, prev[:chainTagLen]), text[i+len(chainTag):]) {
+			return &ChainError{Line: start}
+		}
+		entry = entry[:0]
+		start = line + 1
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if len(entry) > 0 {
+		return &ChainError{Line: start}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	key := []byte("key")
+	var buf bytes.Buffer
+	l := New(NewChainWriter(&buf, key, 2), "", 0)
+	l.Error("one")
+	l.Error("two\nlines")
+	l.Error("#checkpoint 2 not really")
+	l.Error("four")
+	l.Error("five")
+	if err := VerifyChain(bytes.NewReader(buf.Bytes()), key); err != nil {
+		t.Fatalf("VerifyChain: %v\n%s", err, buf.String())
+	}
+	if err := VerifyChain(bytes.NewReader(buf.Bytes()), []byte("other")); err == nil {
+		t.Error("VerifyChain succeeded with the wrong key")
+	}
+
+	// The lines are:
+	//
+	//	1 one #mac=...
+	//	2 two
+	//	3 lines #mac=...
+	//	4 #checkpoint 2 ...
+	//	5 #checkpoint 2 not really #mac=...
+	//	6 four #mac=...
+	//	7 #checkpoint 4 ...
+	//	8 five #mac=...
+	lines := strings.SplitAfter(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("output has %d lines, want 8:\n%s", len(lines), buf.String())
+	}
+	edit := func(fn func(lines []string) []string) string {
+		return strings.Join(fn(append([]string(nil), lines...)), "")
+	}
+	for _, c := range []struct {
+		name string
+		log  string
+		line int
+	}{
+		{"tampered", edit(func(l []string) []string {
+			l[5] = strings.Replace(l[5], "four", "f0ur", 1)
+			return l
+		}), 6},
+		{"tampered continuation", edit(func(l []string) []string {
+			l[1] = "tw0\n"
+			return l
+		}), 2},
+		{"deleted", edit(func(l []string) []string {
+			return append(l[:4], l[5:]...)
+		}), 5},
+		{"reordered", edit(func(l []string) []string {
+			l[4], l[5] = l[5], l[4]
+			return l
+		}), 5},
+		{"tampered checkpoint", edit(func(l []string) []string {
+			l[6] = strings.Replace(l[6], "#checkpoint 4", "#checkpoint 5", 1)
+			return l
+		}), 7},
+		{"truncated", edit(func(l []string) []string {
+			l[7] = strings.TrimSuffix(l[7], "\n")[:10] + "\n"
+			return l
+		}), 8},
+	} {
+		var ce *ChainError
+		if err := VerifyChain(strings.NewReader(c.log), key); !errors.As(err, &ce) || ce.Line != c.line {
+			t.Errorf("%s: VerifyChain = %v, want a ChainError at line %d", c.name, err, c.line)
+		}
+	}
+}
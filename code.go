@@ -0,0 +1,196 @@
+package log
+
+import "sync"
+
+// codes contains the descriptions registered with RegisterCode.
+var codes sync.Map // map[string]string
+
+// RegisterCode registers a human readable description for an event or error
+// code, such as "E1042". Codes do not need to be registered to be logged.
+func RegisterCode(code, description string) {
+	codes.Store(code, description)
+}
+
+// CodeDescription returns the description registered for code, or "" if there
+// is none.
+func CodeDescription(code string) string {
+	if d, ok := codes.Load(code); ok {
+		return d.(string)
+	}
+	return ""
+}
+
+// Errorc logs v at error level with a stable event or error code, such as
+// "E1042", that does not change when the message does. The code is shown as a
+// [E1042] segment after the label, and is available as Entry.Code to the error
+// reporter. Arguments are handled in the manner of fmt.Print.
+func (l *Logger) Errorc(code string, v ...interface{}) {
+	if l.suppressed(LevelError) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelError) {
+		l.extra.code = code
+		l.format(LevelError, l.sprint(v...))
+	}
+}
+
+// Errorcf is like Errorc, but arguments are handled in the manner of
+// fmt.Printf.
+func (l *Logger) Errorcf(code string, format string, v ...interface{}) {
+	if l.suppressed(LevelError) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelError) {
+		l.extra.code = code
+		l.format(LevelError, l.sprintf(format, v...))
+	}
+}
+
+func (l *Logger) Warnc(code string, v ...interface{}) {
+	if l.suppressed(LevelWarn) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelWarn) {
+		l.extra.code = code
+		l.format(LevelWarn, l.sprint(v...))
+	}
+}
+
+func (l *Logger) Warncf(code string, format string, v ...interface{}) {
+	if l.suppressed(LevelWarn) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelWarn) {
+		l.extra.code = code
+		l.format(LevelWarn, l.sprintf(format, v...))
+	}
+}
+
+func (l *Logger) Infoc(code string, v ...interface{}) {
+	if l.suppressed(LevelInfo) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelInfo) {
+		l.extra.code = code
+		l.format(LevelInfo, l.sprint(v...))
+	}
+}
+
+func (l *Logger) Infocf(code string, format string, v ...interface{}) {
+	if l.suppressed(LevelInfo) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelInfo) {
+		l.extra.code = code
+		l.format(LevelInfo, l.sprintf(format, v...))
+	}
+}
+
+// Fatalc is like Errorc, but logs at fatal level and then calls os.Exit(1).
+func (l *Logger) Fatalc(code string, v ...interface{}) {
+	locked := l.lock()
+	if l.enabled(LevelFatal) {
+		l.extra.code = code
+		l.format(LevelFatal, l.sprint(v...))
+	}
+	l.fatalExit(locked, 1)
+}
+
+func (l *Logger) Fatalcf(code string, format string, v ...interface{}) {
+	locked := l.lock()
+	if l.enabled(LevelFatal) {
+		l.extra.code = code
+		l.format(LevelFatal, l.sprintf(format, v...))
+	}
+	l.fatalExit(locked, 1)
+}
+
+func Errorc(code string, v ...interface{}) {
+	if std.suppressed(LevelError) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelError) {
+		std.extra.code = code
+		std.format(LevelError, std.sprint(v...))
+	}
+}
+
+func Errorcf(code string, format string, v ...interface{}) {
+	if std.suppressed(LevelError) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelError) {
+		std.extra.code = code
+		std.format(LevelError, std.sprintf(format, v...))
+	}
+}
+
+func Warnc(code string, v ...interface{}) {
+	if std.suppressed(LevelWarn) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelWarn) {
+		std.extra.code = code
+		std.format(LevelWarn, std.sprint(v...))
+	}
+}
+
+func Warncf(code string, format string, v ...interface{}) {
+	if std.suppressed(LevelWarn) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelWarn) {
+		std.extra.code = code
+		std.format(LevelWarn, std.sprintf(format, v...))
+	}
+}
+
+func Infoc(code string, v ...interface{}) {
+	if std.suppressed(LevelInfo) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelInfo) {
+		std.extra.code = code
+		std.format(LevelInfo, std.sprint(v...))
+	}
+}
+
+func Infocf(code string, format string, v ...interface{}) {
+	if std.suppressed(LevelInfo) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelInfo) {
+		std.extra.code = code
+		std.format(LevelInfo, std.sprintf(format, v...))
+	}
+}
+
+func Fatalc(code string, v ...interface{}) {
+	locked := std.lock()
+	if std.enabled(LevelFatal) {
+		std.extra.code = code
+		std.format(LevelFatal, std.sprint(v...))
+	}
+	std.fatalExit(locked, 1)
+}
+
+func Fatalcf(code string, format string, v ...interface{}) {
+	locked := std.lock()
+	if std.enabled(LevelFatal) {
+		std.extra.code = code
+		std.format(LevelFatal, std.sprintf(format, v...))
+	}
+	std.fatalExit(locked, 1)
+}
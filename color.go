@@ -0,0 +1,79 @@
+package log
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// MessageColor returns the color of the message with Lcolor, see
+// SetMessageColor.
+func (l *Logger) MessageColor() int {
+	defer l.unlock(l.lock())
+	return l.mcolor
+}
+
+// SetMessageColor sets the ANSI color code, such as 37 for white, in which the
+// message of each entry is written with Lcolor. The default is 0, which leaves
+// the message in the default color of the terminal, so that only the label is
+// colored.
+func (l *Logger) SetMessageColor(color int) {
+	defer l.unlock(l.lock())
+	l.mcolor = color
+}
+
+func MessageColor() int {
+	return std.MessageColor()
+}
+
+func SetMessageColor(color int) {
+	std.SetMessageColor(color)
+}
+
+// ColorEnabled reports whether entries are written with color: with Lcolor,
+// or with LcolorAuto if the output is a terminal, but never with LJSON or Llogfmt.
+//
+// With LcolorAuto, the output is checked when the logger is created and when
+// SetOutput is called. Writers other than files, and files that are not a
+// terminal, are written without color. The NO_COLOR environment variable
+// disables color, while FORCE_COLOR enables it even if the output is not a
+// terminal; FORCE_COLOR takes precedence, unless it is "0" or "false". On
+// Windows, the processing of color sequences is enabled for the console if
+// needed; if that fails, color is disabled.
+func (l *Logger) ColorEnabled() bool {
+	defer l.unlock(l.lock())
+	return l.colored()
+}
+
+func ColorEnabled() bool {
+	return std.ColorEnabled()
+}
+
+// colored reports whether entries are written with color. It must be called
+// with l.mu held.
+func (l *Logger) colored() bool {
+	if l.flag&(LJSON|Llogfmt) != 0 {
+		return false
+	}
+	return l.flag&Lcolor != 0 || l.flag&LcolorAuto != 0 && l.autoc
+}
+
+// autoColor reports whether output to w is colored with LcolorAuto.
+func autoColor(w io.Writer) bool {
+	switch os.Getenv("FORCE_COLOR") {
+	case "", "0", "false":
+	default:
+		return true
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	file, ok := w.(interface {
+		Fd() uintptr
+	})
+	if !ok || !terminal.IsTerminal(int(file.Fd())) {
+		return false
+	}
+	return enableColor(file.Fd())
+}
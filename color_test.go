@@ -0,0 +1,94 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestColorPercent(t *testing.T) {
+	tests := []struct {
+		name  string
+		color int
+		log   func(l *Logger)
+		want  string
+	}{
+		{
+			name: "verbs in message",
+			log:  func(l *Logger) { l.Error("%d items, %s") },
+			want: "[\x1b[31mERROR\x1b[0m] %d items, %s\n",
+		},
+		{
+			name: "literal percent",
+			log:  func(l *Logger) { l.Error("100%") },
+			want: "[\x1b[31mERROR\x1b[0m] 100%\n",
+		},
+		{
+			name: "percent in argument",
+			log:  func(l *Logger) { l.Errorf("%s done", "50%") },
+			want: "[\x1b[31mERROR\x1b[0m] 50% done\n",
+		},
+		{
+			name:  "message color",
+			color: 37,
+			log:   func(l *Logger) { l.Errorf("%d%%", 5) },
+			want:  "[\x1b[31mERROR\x1b[0m] \x1b[37m5%\x1b[0m\n",
+		},
+		{
+			name:  "message color with newline",
+			color: 37,
+			log:   func(l *Logger) { l.Errorln("%s") },
+			want:  "[\x1b[31mERROR\x1b[0m] \x1b[37m%s\x1b[0m\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, "", Llabel|Lcolor)
+			l.SetMessageColor(tt.color)
+			tt.log(l)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoColorPercent(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", Llabel)
+	l.Error("100% %d")
+	if got, want := buf.String(), "[ERROR] 100% %d\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCallerLink(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", Lshortfile|Lcolor)
+	l.SetCallerLink("vscode://file/{file}:{line}")
+
+	// Links are only written to a terminal.
+	l.Error("plain")
+	if got := buf.String(); strings.Contains(got, "\x1b]8;") {
+		t.Errorf("output to a buffer contains a link: %q", got)
+	}
+
+	buf.Reset()
+	l.isTerm = true
+	_, file, line, _ := runtime.Caller(0)
+	l.Error("linked")
+	want := fmt.Sprintf("\x1b]8;;vscode://file/%s:%d\x1b\\color_test.go:%d\x1b]8;;\x1b\\: linked\n", file, line+1, line+1)
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	l.SetFlags(Lshortfile)
+	l.Error("without color")
+	if got := buf.String(); strings.Contains(got, "\x1b]8;") {
+		t.Errorf("output without color contains a link: %q", got)
+	}
+}
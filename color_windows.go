@@ -0,0 +1,25 @@
+//go:build windows
+
+package log
+
+import "syscall"
+
+// enableVirtualTerminalProcessing is the console mode that makes the console
+// interpret ANSI escape sequences.
+const enableVirtualTerminalProcessing = 0x0004
+
+var setConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+// enableColor enables the processing of ANSI escape sequences by the console
+// that fd refers to, and reports whether it succeeded.
+func enableColor(fd uintptr) bool {
+	var mode uint32
+	if err := syscall.GetConsoleMode(syscall.Handle(fd), &mode); err != nil {
+		return false
+	}
+	if mode&enableVirtualTerminalProcessing != 0 {
+		return true
+	}
+	ok, _, _ := setConsoleMode.Call(fd, uintptr(mode|enableVirtualTerminalProcessing))
+	return ok != 0
+}
@@ -0,0 +1,30 @@
+package log
+
+// ColumnWidths returns the widths of the caller and the label in the header,
+// see SetColumnWidths.
+func (l *Logger) ColumnWidths() (caller, label int) {
+	defer l.unlock(l.lock())
+	return l.ccol, l.lcol
+}
+
+// SetColumnWidths aligns the header in columns, so that the message of every
+// entry starts at the same column. The caller, as written with Lshortfile or
+// Llongfile, is padded with spaces to caller characters, or truncated in the
+// middle if it is longer: the start of the file name is replaced with "…", as
+// in "…er/handler.go:412", but the line number is always kept. The label, as
+// written with Llabel, is padded to label characters. Labels are already
+// padded to the longest registered label, so label only needs to be set for
+// alignment with other programs. A width of 0, the default, disables the
+// alignment of the corresponding column.
+func (l *Logger) SetColumnWidths(caller, label int) {
+	defer l.unlock(l.lock())
+	l.ccol, l.lcol = caller, label
+}
+
+func ColumnWidths() (caller, label int) {
+	return std.ColumnWidths()
+}
+
+func SetColumnWidths(caller, label int) {
+	std.SetColumnWidths(caller, label)
+}
@@ -0,0 +1,189 @@
+package log
+
+import (
+	"io"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxPooledBuffer is the capacity above which a buffer is not returned to the
+// pool, so that one huge entry does not keep its memory alive.
+const maxPooledBuffer = 64 << 10
+
+// bufPool holds the buffers in which entries are formatted.
+var bufPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 256)
+		return &b
+	},
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *[]byte {
+	b := bufPool.Get().(*[]byte)
+	*b = (*b)[:0]
+	return b
+}
+
+// putBuffer returns b to the pool.
+func putBuffer(b *[]byte) {
+	if cap(*b) <= maxPooledBuffer {
+		bufPool.Put(b)
+	}
+}
+
+// writeEntry writes the header for flag and prefix followed by s to w, with a
+// newline appended if s does not end in one, in a single call to w.Write. It
+// writes the same header as the standard log package. The calldepth is
+// counted as for Output, from the caller of writeEntry; 3 + l.skip is the
+// caller of the logging method when it is called directly by format.
+func (l *Logger) writeEntry(w io.Writer, flag int, prefix string, calldepth int, s string) error {
+	now := l.now()
+	var c caller
+	if flag&(Lshortfile|Llongfile) != 0 {
+		// Unlike runtime.Caller, this does not allocate.
+		var pc [1]uintptr
+		c.file = "???"
+		if runtime.Callers(calldepth+1, pc[:]) > 0 {
+			if f := runtime.FuncForPC(pc[0] - 1); f != nil {
+				c.file, c.line = f.FileLine(pc[0] - 1)
+			}
+		}
+		c.link = l.callerLink(c.file, c.line)
+		c.width = l.ccol
+	}
+	b := getBuffer()
+	defer putBuffer(b)
+	*b = appendHeader(*b, flag, prefix, now, c)
+	*b = append(*b, s...)
+	if len(s) == 0 || s[len(s)-1] != '\n' {
+		*b = append(*b, '\n')
+	}
+	_, err := w.Write(*b)
+	return err
+}
+
+// caller is the caller segment of a header.
+type caller struct {
+	file  string
+	line  int
+	link  string // URL of a hyperlink around file:line, or ""
+	width int    // column width, see SetColumnWidths, or 0
+}
+
+// appendHeader appends the header of an entry to b, as described for the
+// flags. With Llabel, which doubles as the standard Lmsgprefix flag, the
+// prefix follows the rest of the header rather than preceding it.
+func appendHeader(b []byte, flag int, prefix string, t time.Time, c caller) []byte {
+	if flag&Llabel == 0 {
+		b = append(b, prefix...)
+	}
+	if flag&(Ldate|Ltime|Lmicroseconds) != 0 {
+		if flag&LUTC != 0 {
+			t = t.UTC()
+		}
+		if flag&Ldate != 0 {
+			year, month, day := t.Date()
+			b = appendInt(b, year, 4)
+			b = append(b, '/')
+			b = appendInt(b, int(month), 2)
+			b = append(b, '/')
+			b = appendInt(b, day, 2)
+			b = append(b, ' ')
+		}
+		if flag&(Ltime|Lmicroseconds) != 0 {
+			hour, min, sec := t.Clock()
+			b = appendInt(b, hour, 2)
+			b = append(b, ':')
+			b = appendInt(b, min, 2)
+			b = append(b, ':')
+			b = appendInt(b, sec, 2)
+			if flag&Lmicroseconds != 0 {
+				b = append(b, '.')
+				b = appendInt(b, t.Nanosecond()/1e3, 6)
+			}
+			b = append(b, ' ')
+		}
+	}
+	if flag&(Lshortfile|Llongfile) != 0 {
+		if flag&Lshortfile != 0 {
+			for i := len(c.file) - 1; i > 0; i-- {
+				if c.file[i] == '/' {
+					c.file = c.file[i+1:]
+					break
+				}
+			}
+		}
+		b = appendCaller(b, c)
+	}
+	if flag&Llabel != 0 {
+		b = append(b, prefix...)
+	}
+	return b
+}
+
+// appendCaller appends the caller segment c, followed by ": ", to b.
+func appendCaller(b []byte, c caller) []byte {
+	if c.link == "" && c.width <= 0 {
+		b = append(b, c.file...)
+		b = append(b, ':')
+		b = appendInt(b, c.line, 0)
+		return append(b, ": "...)
+	}
+	line := strconv.Itoa(c.line)
+	s, pad := c.file+":"+line, 0
+	if c.width > 0 {
+		s, pad = fitColumn(s, c.width, len(line)+1)
+	}
+	if c.link != "" {
+		b = appendLink(b, c.link, s)
+	} else {
+		b = append(b, s...)
+	}
+	b = append(b, ": "...)
+	for ; pad > 0; pad-- {
+		b = append(b, ' ')
+	}
+	return b
+}
+
+// fitColumn fits s into width characters. A longer s is truncated in the
+// middle: its start is replaced with "…", but its last keep bytes are always
+// kept. It returns the result and the number of spaces needed to pad it to
+// width.
+func fitColumn(s string, width, keep int) (string, int) {
+	n := utf8.RuneCountInString(s)
+	if n <= width {
+		return s, width - n
+	}
+	tail := width - 1
+	if tail < keep {
+		tail = keep
+	}
+	for i := range s {
+		if n <= tail {
+			return "…" + s[i:], 0
+		}
+		n--
+	}
+	return s, 0
+}
+
+// appendInt appends the decimal form of the non-negative i to b, zero-padded to
+// width digits.
+func appendInt(b []byte, i, width int) []byte {
+	var buf [20]byte
+	n := len(buf) - 1
+	for i >= 10 || width > 1 {
+		width--
+		q := i / 10
+		buf[n] = byte('0' + i - q*10)
+		n--
+		i = q
+	}
+	buf[n] = byte('0' + i)
+	return append(b, buf[n:]...)
+}
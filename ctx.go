@@ -0,0 +1,409 @@
+package log
+
+import "context"
+
+// contextKey is the key of the contextValue stored by NewContext and
+// ContextWithLogger.
+type contextKey struct{}
+
+// contextValue holds the fields and the logger carried by a context.
+type contextValue struct {
+	fields []Field
+	logger *Logger
+}
+
+// NewContext returns a copy of ctx that carries the given key/value pairs, in
+// addition to those already carried by ctx. They are handled like the pairs
+// passed to With, and are logged with every entry written by one of the *Ctx
+// methods, like InfoCtx, after the fields of the Logger. Middleware can use it
+// to attach a request ID to every entry logged while handling a request:
+//
+//	ctx := log.NewContext(r.Context(), "request_id", id)
+//	...
+//	log.InfoCtx(ctx, "user logged in")
+func NewContext(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	v := contextValue{}
+	if cv, ok := ctx.Value(contextKey{}).(*contextValue); ok {
+		v = *cv
+	}
+	v.fields = appendPairs(v.fields[:len(v.fields):len(v.fields)], keysAndValues)
+	return context.WithValue(ctx, contextKey{}, &v)
+}
+
+// ContextWithLogger returns a copy of ctx that carries l, to be returned by
+// FromContext. The fields carried by ctx are kept.
+func ContextWithLogger(ctx context.Context, l *Logger) context.Context {
+	v := contextValue{}
+	if cv, ok := ctx.Value(contextKey{}).(*contextValue); ok {
+		v = *cv
+	}
+	v.logger = l
+	return context.WithValue(ctx, contextKey{}, &v)
+}
+
+// FromContext returns the Logger carried by ctx, or the standard logger if it
+// carries none. If ctx carries fields, the returned Logger logs them with
+// every entry, as if they were passed to With. A nil ctx is allowed.
+func FromContext(ctx context.Context) *Logger {
+	if ctx == nil {
+		return std
+	}
+	v, ok := ctx.Value(contextKey{}).(*contextValue)
+	if !ok {
+		return std
+	}
+	l := v.logger
+	if l == nil {
+		l = std
+	}
+	if len(v.fields) == 0 {
+		return l
+	}
+	d := &Logger{
+		logger: l.logger,
+		every:  l.every,
+		comp:   l.comp,
+		with:   append(l.with[:len(l.with):len(l.with)], v.fields...),
+	}
+	d.depth.Store(l.depth.Load())
+	return d
+}
+
+// contextFields returns the fields carried by ctx, which may be nil.
+func contextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	if v, ok := ctx.Value(contextKey{}).(*contextValue); ok {
+		return v.fields
+	}
+	return nil
+}
+
+// addContextFields adds the fields carried by ctx to the entry being logged,
+// and keeps ctx to be passed to the slog handler and the runtime tracer. It
+// must be called with l.mu held.
+func (l *Logger) addContextFields(ctx context.Context) {
+	l.extra.ctx = ctx
+	if fields := contextFields(ctx); len(fields) > 0 {
+		l.extra.fields = append(l.extra.fields, fields...)
+	}
+}
+
+// FatalCtx is like Fatal, but also logs the fields carried by ctx, see
+// NewContext.
+func (l *Logger) FatalCtx(ctx context.Context, v ...interface{}) {
+	locked := l.lock()
+	if l.enabled(LevelFatal) {
+		l.addContextFields(ctx)
+		l.format(LevelFatal, l.sprint(v...))
+	}
+	l.fatalExit(locked, 1)
+}
+
+func (l *Logger) FatalCtxf(ctx context.Context, format string, v ...interface{}) {
+	locked := l.lock()
+	if l.enabled(LevelFatal) {
+		l.addContextFields(ctx)
+		l.format(LevelFatal, l.sprintf(format, v...))
+	}
+	l.fatalExit(locked, 1)
+}
+
+func (l *Logger) FatalCtxln(ctx context.Context, v ...interface{}) {
+	locked := l.lock()
+	if l.enabled(LevelFatal) {
+		l.addContextFields(ctx)
+		l.format(LevelFatal, l.sprintln(v...))
+	}
+	l.fatalExit(locked, 1)
+}
+
+// PanicCtx is like Panic, but also logs the fields carried by ctx.
+func (l *Logger) PanicCtx(ctx context.Context, v ...interface{}) {
+	defer l.unlock(l.lock())
+	s := l.sprint(v...)
+	if l.enabled(LevelPanic) {
+		l.addContextFields(ctx)
+		l.format(LevelPanic, s)
+	}
+	panic(s)
+}
+
+func (l *Logger) PanicCtxf(ctx context.Context, format string, v ...interface{}) {
+	defer l.unlock(l.lock())
+	s := l.sprintf(format, v...)
+	if l.enabled(LevelPanic) {
+		l.addContextFields(ctx)
+		l.format(LevelPanic, s)
+	}
+	panic(s)
+}
+
+func (l *Logger) PanicCtxln(ctx context.Context, v ...interface{}) {
+	defer l.unlock(l.lock())
+	s := l.sprintln(v...)
+	if l.enabled(LevelPanic) {
+		l.addContextFields(ctx)
+		l.format(LevelPanic, s)
+	}
+	panic(s)
+}
+
+// ErrorCtx is like Error, but also logs the fields carried by ctx.
+func (l *Logger) ErrorCtx(ctx context.Context, v ...interface{}) {
+	if l.suppressed(LevelError) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelError) {
+		l.addContextFields(ctx)
+		l.format(LevelError, l.sprint(v...))
+	}
+}
+
+func (l *Logger) ErrorCtxf(ctx context.Context, format string, v ...interface{}) {
+	if l.suppressed(LevelError) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelError) {
+		l.addContextFields(ctx)
+		l.format(LevelError, l.sprintf(format, v...))
+	}
+}
+
+func (l *Logger) ErrorCtxln(ctx context.Context, v ...interface{}) {
+	if l.suppressed(LevelError) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelError) {
+		l.addContextFields(ctx)
+		l.format(LevelError, l.sprintln(v...))
+	}
+}
+
+// WarnCtx is like Warn, but also logs the fields carried by ctx.
+func (l *Logger) WarnCtx(ctx context.Context, v ...interface{}) {
+	if l.suppressed(LevelWarn) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelWarn) {
+		l.addContextFields(ctx)
+		l.format(LevelWarn, l.sprint(v...))
+	}
+}
+
+func (l *Logger) WarnCtxf(ctx context.Context, format string, v ...interface{}) {
+	if l.suppressed(LevelWarn) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelWarn) {
+		l.addContextFields(ctx)
+		l.format(LevelWarn, l.sprintf(format, v...))
+	}
+}
+
+func (l *Logger) WarnCtxln(ctx context.Context, v ...interface{}) {
+	if l.suppressed(LevelWarn) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelWarn) {
+		l.addContextFields(ctx)
+		l.format(LevelWarn, l.sprintln(v...))
+	}
+}
+
+// InfoCtx is like Info, but also logs the fields carried by ctx.
+func (l *Logger) InfoCtx(ctx context.Context, v ...interface{}) {
+	if l.suppressed(LevelInfo) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelInfo) {
+		l.addContextFields(ctx)
+		l.format(LevelInfo, l.sprint(v...))
+	}
+}
+
+func (l *Logger) InfoCtxf(ctx context.Context, format string, v ...interface{}) {
+	if l.suppressed(LevelInfo) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelInfo) {
+		l.addContextFields(ctx)
+		l.format(LevelInfo, l.sprintf(format, v...))
+	}
+}
+
+func (l *Logger) InfoCtxln(ctx context.Context, v ...interface{}) {
+	if l.suppressed(LevelInfo) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelInfo) {
+		l.addContextFields(ctx)
+		l.format(LevelInfo, l.sprintln(v...))
+	}
+}
+
+func FatalCtx(ctx context.Context, v ...interface{}) {
+	locked := std.lock()
+	if std.enabled(LevelFatal) {
+		std.addContextFields(ctx)
+		std.format(LevelFatal, std.sprint(v...))
+	}
+	std.fatalExit(locked, 1)
+}
+
+func FatalCtxf(ctx context.Context, format string, v ...interface{}) {
+	locked := std.lock()
+	if std.enabled(LevelFatal) {
+		std.addContextFields(ctx)
+		std.format(LevelFatal, std.sprintf(format, v...))
+	}
+	std.fatalExit(locked, 1)
+}
+
+func FatalCtxln(ctx context.Context, v ...interface{}) {
+	locked := std.lock()
+	if std.enabled(LevelFatal) {
+		std.addContextFields(ctx)
+		std.format(LevelFatal, std.sprintln(v...))
+	}
+	std.fatalExit(locked, 1)
+}
+
+func PanicCtx(ctx context.Context, v ...interface{}) {
+	defer std.unlock(std.lock())
+	s := std.sprint(v...)
+	if std.enabled(LevelPanic) {
+		std.addContextFields(ctx)
+		std.format(LevelPanic, s)
+	}
+	panic(s)
+}
+
+func PanicCtxf(ctx context.Context, format string, v ...interface{}) {
+	defer std.unlock(std.lock())
+	s := std.sprintf(format, v...)
+	if std.enabled(LevelPanic) {
+		std.addContextFields(ctx)
+		std.format(LevelPanic, s)
+	}
+	panic(s)
+}
+
+func PanicCtxln(ctx context.Context, v ...interface{}) {
+	defer std.unlock(std.lock())
+	s := std.sprintln(v...)
+	if std.enabled(LevelPanic) {
+		std.addContextFields(ctx)
+		std.format(LevelPanic, s)
+	}
+	panic(s)
+}
+
+func ErrorCtx(ctx context.Context, v ...interface{}) {
+	if std.suppressed(LevelError) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelError) {
+		std.addContextFields(ctx)
+		std.format(LevelError, std.sprint(v...))
+	}
+}
+
+func ErrorCtxf(ctx context.Context, format string, v ...interface{}) {
+	if std.suppressed(LevelError) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelError) {
+		std.addContextFields(ctx)
+		std.format(LevelError, std.sprintf(format, v...))
+	}
+}
+
+func ErrorCtxln(ctx context.Context, v ...interface{}) {
+	if std.suppressed(LevelError) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelError) {
+		std.addContextFields(ctx)
+		std.format(LevelError, std.sprintln(v...))
+	}
+}
+
+func WarnCtx(ctx context.Context, v ...interface{}) {
+	if std.suppressed(LevelWarn) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelWarn) {
+		std.addContextFields(ctx)
+		std.format(LevelWarn, std.sprint(v...))
+	}
+}
+
+func WarnCtxf(ctx context.Context, format string, v ...interface{}) {
+	if std.suppressed(LevelWarn) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelWarn) {
+		std.addContextFields(ctx)
+		std.format(LevelWarn, std.sprintf(format, v...))
+	}
+}
+
+func WarnCtxln(ctx context.Context, v ...interface{}) {
+	if std.suppressed(LevelWarn) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelWarn) {
+		std.addContextFields(ctx)
+		std.format(LevelWarn, std.sprintln(v...))
+	}
+}
+
+func InfoCtx(ctx context.Context, v ...interface{}) {
+	if std.suppressed(LevelInfo) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelInfo) {
+		std.addContextFields(ctx)
+		std.format(LevelInfo, std.sprint(v...))
+	}
+}
+
+func InfoCtxf(ctx context.Context, format string, v ...interface{}) {
+	if std.suppressed(LevelInfo) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelInfo) {
+		std.addContextFields(ctx)
+		std.format(LevelInfo, std.sprintf(format, v...))
+	}
+}
+
+func InfoCtxln(ctx context.Context, v ...interface{}) {
+	if std.suppressed(LevelInfo) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelInfo) {
+		std.addContextFields(ctx)
+		std.format(LevelInfo, std.sprintln(v...))
+	}
+}
@@ -0,0 +1,66 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", Llabel)
+	l.SetLevel(LevelDebug)
+	ctx := NewContext(context.Background(), "req", 7)
+	ctx = NewContext(ctx, "tenant", "a")
+
+	l.InfoCtxf(ctx, "hi %d", 1)
+	l.With("x", 1).WarnCtx(nil, "no context")
+	FromContext(ContextWithLogger(ctx, l)).Debug("from context")
+	want := "[INFO ] hi 1 req=7 tenant=a\n" +
+		"[WARN ] no context x=1\n" +
+		"[DEBUG] from context req=7 tenant=a\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestFromContextDefault(t *testing.T) {
+	if FromContext(context.Background()) != std {
+		t.Error("FromContext without a logger does not return the standard logger")
+	}
+	if FromContext(nil) != std {
+		t.Error("FromContext(nil) does not return the standard logger")
+	}
+}
+
+func TestContextAllocs(t *testing.T) {
+	l := New(&bytes.Buffer{}, "", 0)
+	l.SetLevel(LevelInfo)
+	ctx := context.Background()
+	plain := testing.AllocsPerRun(100, func() { l.Info("x") })
+	withCtx := testing.AllocsPerRun(100, func() { l.InfoCtx(ctx, "x") })
+	if withCtx > plain {
+		t.Errorf("InfoCtx without fields allocates %v times, Info %v times", withCtx, plain)
+	}
+}
+
+type spanKey struct{}
+
+func TestContextSlog(t *testing.T) {
+	h := &recordingHandler{min: slog.LevelInfo}
+	l := NewSlogBackend(h)
+	ctx := context.WithValue(context.Background(), spanKey{}, "span-1")
+
+	l.InfoCtx(ctx, "with context")
+	l.Info("without context")
+	if len(h.ctxs) != 2 {
+		t.Fatalf("handler got %d entries, want 2", len(h.ctxs))
+	}
+	if got := h.ctxs[0].Value(spanKey{}); got != "span-1" {
+		t.Errorf("handler got context value %v for InfoCtx, want span-1", got)
+	}
+	if h.ctxs[1] == nil || h.ctxs[1].Value(spanKey{}) != nil {
+		t.Errorf("handler got context %v for Info, want the background context", h.ctxs[1])
+	}
+}
@@ -0,0 +1,255 @@
+//go:build !log_nodebug
+
+package log
+
+import "context"
+
+// DebugCompiled reports whether debug logging is compiled in. It is false when
+// building with the log_nodebug tag, in which case the Debug methods and
+// functions, like Debugf and DebugFunc, do nothing. Their calls are then
+// inlined and removed by the compiler, but their arguments are still evaluated
+// if that has side effects, like calling a function. Expensive or side-effecting
+// arguments can be guarded with DebugCompiled, which the compiler removes
+// entirely in such builds:
+//
+//	if log.DebugCompiled {
+//		log.Debugf("state: %v", dumpState())
+//	}
+const DebugCompiled = true
+
+// DebugFunc is like InfoFunc, but logs at debug level.
+func (l *Logger) DebugFunc(fn func() string) {
+	if l.suppressed(LevelDebug) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelDebug) {
+		l.format(LevelDebug, l.sprintFunc(fn))
+	} else if l.retro != nil && l.threshold() < LevelDebug {
+		l.keepDebug(l.sprintFunc(fn))
+	}
+}
+
+func (l *Logger) Debug(v ...interface{}) {
+	if l.suppressed(LevelDebug) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelDebug) {
+		l.format(LevelDebug, l.sprint(v...))
+	} else if l.retro != nil && l.threshold() < LevelDebug {
+		l.keepDebug(l.sprint(v...))
+	}
+}
+
+func (l *Logger) Debugln(v ...interface{}) {
+	if l.suppressed(LevelDebug) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelDebug) {
+		l.format(LevelDebug, l.sprintln(v...))
+	} else if l.retro != nil && l.threshold() < LevelDebug {
+		l.keepDebug(l.sprintln(v...))
+	}
+}
+
+func (l *Logger) Debugf(format string, v ...interface{}) {
+	if l.suppressed(LevelDebug) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelDebug) {
+		l.format(LevelDebug, l.sprintf(format, v...))
+	} else if l.retro != nil && l.threshold() < LevelDebug {
+		l.keepDebug(l.sprintf(format, v...))
+	}
+}
+
+// Debugt is like Errort, but logs at debug level.
+func (l *Logger) Debugt(template string, fields Fields) {
+	if l.suppressed(LevelDebug) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelDebug) {
+		l.format(LevelDebug, l.template(template, fields))
+	} else if l.retro != nil && l.threshold() < LevelDebug {
+		l.keepDebug(renderTemplate(template, fields, l))
+	}
+}
+
+// Debugw is like Infow, but logs at debug level.
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	if l.suppressed(LevelDebug) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelDebug) {
+		l.extra.fields = appendPairs(l.extra.fields, keysAndValues)
+		l.format(LevelDebug, msg)
+	} else if l.retro != nil && l.threshold() < LevelDebug {
+		l.keepDebug(appendMessage(msg, string(appendFieldList(nil, appendPairs(nil, keysAndValues), l))))
+	}
+}
+
+// DebugCtx is like Debug, but also logs the fields carried by ctx.
+func (l *Logger) DebugCtx(ctx context.Context, v ...interface{}) {
+	if l.suppressed(LevelDebug) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelDebug) {
+		l.addContextFields(ctx)
+		l.format(LevelDebug, l.sprint(v...))
+	} else if l.retro != nil && l.threshold() < LevelDebug {
+		l.keepDebug(appendContextFields(ctx, l.sprint(v...), l))
+	}
+}
+
+func (l *Logger) DebugCtxf(ctx context.Context, format string, v ...interface{}) {
+	if l.suppressed(LevelDebug) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelDebug) {
+		l.addContextFields(ctx)
+		l.format(LevelDebug, l.sprintf(format, v...))
+	} else if l.retro != nil && l.threshold() < LevelDebug {
+		l.keepDebug(appendContextFields(ctx, l.sprintf(format, v...), l))
+	}
+}
+
+func (l *Logger) DebugCtxln(ctx context.Context, v ...interface{}) {
+	if l.suppressed(LevelDebug) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelDebug) {
+		l.addContextFields(ctx)
+		l.format(LevelDebug, l.sprintln(v...))
+	} else if l.retro != nil && l.threshold() < LevelDebug {
+		l.keepDebug(appendContextFields(ctx, l.sprintln(v...), l))
+	}
+}
+
+func DebugFunc(fn func() string) {
+	if std.suppressed(LevelDebug) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelDebug) {
+		std.format(LevelDebug, std.sprintFunc(fn))
+	} else if std.retro != nil && std.threshold() < LevelDebug {
+		std.keepDebug(std.sprintFunc(fn))
+	}
+}
+
+func Debug(v ...interface{}) {
+	if std.suppressed(LevelDebug) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelDebug) {
+		std.format(LevelDebug, std.sprint(v...))
+	} else if std.retro != nil && std.threshold() < LevelDebug {
+		std.keepDebug(std.sprint(v...))
+	}
+}
+
+func Debugln(v ...interface{}) {
+	if std.suppressed(LevelDebug) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelDebug) {
+		std.format(LevelDebug, std.sprintln(v...))
+	} else if std.retro != nil && std.threshold() < LevelDebug {
+		std.keepDebug(std.sprintln(v...))
+	}
+}
+
+func Debugf(format string, v ...interface{}) {
+	if std.suppressed(LevelDebug) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelDebug) {
+		std.format(LevelDebug, std.sprintf(format, v...))
+	} else if std.retro != nil && std.threshold() < LevelDebug {
+		std.keepDebug(std.sprintf(format, v...))
+	}
+}
+
+func Debugt(template string, fields Fields) {
+	if std.suppressed(LevelDebug) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelDebug) {
+		std.format(LevelDebug, std.template(template, fields))
+	} else if std.retro != nil && std.threshold() < LevelDebug {
+		std.keepDebug(renderTemplate(template, fields, std))
+	}
+}
+
+func Debugw(msg string, keysAndValues ...interface{}) {
+	if std.suppressed(LevelDebug) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelDebug) {
+		std.extra.fields = appendPairs(std.extra.fields, keysAndValues)
+		std.format(LevelDebug, msg)
+	} else if std.retro != nil && std.threshold() < LevelDebug {
+		std.keepDebug(appendMessage(msg, string(appendFieldList(nil, appendPairs(nil, keysAndValues), std))))
+	}
+}
+
+func DebugCtx(ctx context.Context, v ...interface{}) {
+	if std.suppressed(LevelDebug) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelDebug) {
+		std.addContextFields(ctx)
+		std.format(LevelDebug, std.sprint(v...))
+	} else if std.retro != nil && std.threshold() < LevelDebug {
+		std.keepDebug(appendContextFields(ctx, std.sprint(v...), std))
+	}
+}
+
+func DebugCtxf(ctx context.Context, format string, v ...interface{}) {
+	if std.suppressed(LevelDebug) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelDebug) {
+		std.addContextFields(ctx)
+		std.format(LevelDebug, std.sprintf(format, v...))
+	} else if std.retro != nil && std.threshold() < LevelDebug {
+		std.keepDebug(appendContextFields(ctx, std.sprintf(format, v...), std))
+	}
+}
+
+func DebugCtxln(ctx context.Context, v ...interface{}) {
+	if std.suppressed(LevelDebug) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelDebug) {
+		std.addContextFields(ctx)
+		std.format(LevelDebug, std.sprintln(v...))
+	} else if std.retro != nil && std.threshold() < LevelDebug {
+		std.keepDebug(appendContextFields(ctx, std.sprintln(v...), std))
+	}
+}
+
+// appendContextFields appends the fields carried by ctx to s, for entries
+// kept by EnableRetroactiveDebug.
+func appendContextFields(ctx context.Context, s string, l *Logger) string {
+	if fields := contextFields(ctx); len(fields) > 0 {
+		return appendMessage(s, string(appendFieldList(nil, fields, l)))
+	}
+	return s
+}
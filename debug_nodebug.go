@@ -0,0 +1,49 @@
+//go:build log_nodebug
+
+package log
+
+import "context"
+
+// DebugCompiled reports whether debug logging is compiled in. It is false in
+// builds with the log_nodebug tag, where the debug logging functions do nothing.
+const DebugCompiled = false
+
+// DebugFunc is like InfoFunc, but logs at debug level.
+func (l *Logger) DebugFunc(fn func() string) {}
+
+func (l *Logger) Debug(v ...interface{}) {}
+
+func (l *Logger) Debugln(v ...interface{}) {}
+
+func (l *Logger) Debugf(format string, v ...interface{}) {}
+
+// Debugt is like Errort, but logs at debug level.
+func (l *Logger) Debugt(template string, fields Fields) {}
+
+// Debugw is like Infow, but logs at debug level.
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {}
+
+// DebugCtx is like Debug, but also logs the fields carried by ctx.
+func (l *Logger) DebugCtx(ctx context.Context, v ...interface{}) {}
+
+func (l *Logger) DebugCtxf(ctx context.Context, format string, v ...interface{}) {}
+
+func (l *Logger) DebugCtxln(ctx context.Context, v ...interface{}) {}
+
+func DebugFunc(fn func() string) {}
+
+func Debug(v ...interface{}) {}
+
+func Debugln(v ...interface{}) {}
+
+func Debugf(format string, v ...interface{}) {}
+
+func Debugt(template string, fields Fields) {}
+
+func Debugw(msg string, keysAndValues ...interface{}) {}
+
+func DebugCtx(ctx context.Context, v ...interface{}) {}
+
+func DebugCtxf(ctx context.Context, format string, v ...interface{}) {}
+
+func DebugCtxln(ctx context.Context, v ...interface{}) {}
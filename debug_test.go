@@ -0,0 +1,53 @@
+//go:build !log_nodebug
+
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+)
+
+func TestDebugFunc(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", Lshortfile)
+	l.SetInternalOutput(io.Discard)
+	l.SetLevel(LevelInfo)
+	called := false
+	l.DebugFunc(func() string { called = true; return "hidden" })
+	if called {
+		t.Error("DebugFunc called fn with debug entries disabled")
+	}
+
+	l.SetLevel(LevelDebug)
+	_, _, line, _ := runtime.Caller(0)
+	l.DebugFunc(func() string { return "state" })
+	l.InfoFunc(func() string { panic("bad state") })
+	want := fmt.Sprintf("debug_test.go:%d: state\n", line+1) +
+		fmt.Sprintf("debug_test.go:%d: !PANIC(string: bad state)\n", line+2)
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func BenchmarkDisabledDebugFunc(b *testing.B) {
+	l := New(io.Discard, "", LstdFlags)
+	state := make([]int, 1000)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.DebugFunc(func() string { return fmt.Sprint(state) })
+		}
+	})
+}
+
+func BenchmarkDebugFunc(b *testing.B) {
+	l := New(io.Discard, "", LstdFlags)
+	l.SetLevel(LevelDebug)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.DebugFunc(func() string { return "state" })
+	}
+}
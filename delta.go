@@ -0,0 +1,33 @@
+package log
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// epoch is the reference for the entry times kept for Ldelta, which are
+// monotonic durations since epoch.
+var epoch = time.Now()
+
+// delta returns the Ldelta segment of an entry: the time since the previous
+// entry, like "(+12.4ms) ". The first entry shows (+0s). If record is true, the
+// entry becomes the previous one.
+func (l *Logger) delta(record bool) string {
+	return deltaSince(&l.last, record)
+}
+
+// deltaSince is like delta, but keeps the time of the previous entry in last.
+func deltaSince(last *atomic.Int64, record bool) string {
+	now := int64(time.Since(epoch))
+	var prev int64
+	if record {
+		prev = last.Swap(now)
+	} else {
+		prev = last.Load()
+	}
+	var d time.Duration
+	if prev != 0 {
+		d = time.Duration(now - prev)
+	}
+	return "(+" + duration(d).human() + ") "
+}
@@ -0,0 +1,130 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// KeepRecent makes l keep the last n entries, of all levels, for DumpOnSignal.
+// The entries are kept as their message and fields, marked with their level
+// label, like "[INFO] connected addr=db:5432". If retroactive debugging is
+// enabled, see EnableRetroactiveDebug, the debug entries suppressed by the log
+// level are kept as well. An n of 0 stops keeping entries, which is the
+// default.
+func (l *Logger) KeepRecent(n int) {
+	defer l.unlock(l.lock())
+	if n <= 0 {
+		l.recent = nil
+		return
+	}
+	l.recent = &retro{msgs: make([]string, n)}
+}
+
+func KeepRecent(n int) {
+	std.KeepRecent(n)
+}
+
+// DumpOnSignal writes the entries kept with KeepRecent whenever the process
+// receives sig, like syscall.SIGUSR2. Without KeepRecent, it writes the debug
+// entries kept for retroactive debugging, see EnableRetroactiveDebug, instead.
+// The entries are not discarded by the dump.
+//
+// If dest is empty or "stderr", the dump is written to os.Stderr. Otherwise
+// dest is a directory, in which each dump is written to a new file named after
+// the time of the dump, like log-dump-20240131-150405.txt, with a sequence
+// number added for further dumps within the same second. The dump is delimited
+// by a header and footer line. Only copying the entries holds up logging; they
+// are written by a separate goroutine. The returned function stops listening
+// for sig.
+func (l *Logger) DumpOnSignal(sig os.Signal, dest string) (stop func()) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sig)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			case s := <-c:
+				if err := l.dump(s, dest); err != nil {
+					l.internalf("dumping entries: %v", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(done)
+		<-stopped
+	}
+}
+
+func DumpOnSignal(sig os.Signal, dest string) (stop func()) {
+	return std.DumpOnSignal(sig, dest)
+}
+
+// dump writes the kept entries to dest, upon receiving sig.
+func (l *Logger) dump(sig os.Signal, dest string) error {
+	var msgs []string
+	locked := l.lock()
+	if l.recent != nil {
+		msgs = l.recent.snapshot()
+	} else if l.retro != nil {
+		msgs = l.retro.snapshot()
+	}
+	l.unlock(locked)
+
+	now := time.Now()
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "=== log dump on %v at %s: %d entries ===\n", sig, now.Format(time.RFC3339), len(msgs))
+	for _, s := range msgs {
+		b.WriteString(s)
+		if len(s) == 0 || s[len(s)-1] != '\n' {
+			b.WriteByte('\n')
+		}
+	}
+	b.WriteString("=== end of log dump ===\n")
+
+	if dest == "" || dest == "stderr" {
+		_, err := os.Stderr.Write(b.Bytes())
+		return err
+	}
+	f, err := createDump(dest, now)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, &b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// maxDumpsPerSecond is the number of dump files that createDump can create
+// within the same second.
+const maxDumpsPerSecond = 100
+
+// createDump creates a new dump file in dir, named after t. Should a file by
+// that name exist, as happens with several dumps within a second, a sequence
+// number is added to the name, like log-dump-20240131-150405-1.txt.
+func createDump(dir string, t time.Time) (*os.File, error) {
+	base := filepath.Join(dir, "log-dump-"+t.Format("20060102-150405"))
+	name := base + ".txt"
+	for i := 1; ; i++ {
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if !errors.Is(err, fs.ErrExist) || i == maxDumpsPerSecond {
+			return f, err
+		}
+		name = base + "-" + strconv.Itoa(i) + ".txt"
+	}
+}
@@ -0,0 +1,79 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readDump dumps the entries kept by l to a new directory and returns the
+// dump.
+func readDump(t *testing.T, l *Logger) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := l.dump(os.Interrupt, dir); err != nil {
+		t.Fatalf("dump: %v", err)
+	}
+	names, _ := filepath.Glob(filepath.Join(dir, "log-dump-*.txt"))
+	if len(names) != 1 {
+		t.Fatalf("dump wrote %d files, want 1", len(names))
+	}
+	b, err := os.ReadFile(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestDumpRecent(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	l.SetLevel(LevelInfo)
+	l.KeepRecent(3)
+	l.EnableRetroactiveDebug(10)
+	l.Info("one")
+	l.Debug("suppressed")
+	l.With("k", 1).Warn("two")
+	l.Error("three")
+
+	got := readDump(t, l)
+	want := "3 entries ===\n[DEBUG] suppressed\n[WARN] two k=1\n[ERROR] three\n=== end of log dump ===\n"
+	if !strings.HasPrefix(got, "=== log dump on interrupt at ") || !strings.HasSuffix(got, want) {
+		t.Errorf("dump = %q, want suffix %q", got, want)
+	}
+}
+
+func TestDumpRetro(t *testing.T) {
+	l := New(&bytes.Buffer{}, "", 0)
+	l.EnableRetroactiveDebug(3)
+	l.Info("not kept")
+	l.Debug("a")
+	l.Debugln("b")
+
+	if got, want := readDump(t, l), "2 entries ===\na\nb\n=== end of log dump ===\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("dump = %q, want suffix %q", got, want)
+	}
+}
+
+func TestDumpSameSecond(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 1, 31, 15, 4, 5, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		f, err := createDump(dir, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+	names, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for i := range names {
+		names[i] = filepath.Base(names[i])
+	}
+	want := "log-dump-20240131-150405-1.txt log-dump-20240131-150405-2.txt log-dump-20240131-150405.txt"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("files %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package log
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Encrypted output consists of frames, one for each call to Write:
+//
+//	length (4 bytes, big endian) | key ID (1 byte) | nonce (12 bytes) | ciphertext
+//
+// where length is the number of bytes following it. The ciphertext is the
+// AES-GCM sealed data, authenticated together with the key ID. A truncated
+// output loses at most its last frame.
+const (
+	frameHeaderLen = 4
+	maxFrameLen    = 16 << 20
+)
+
+// ErrUnknownKey is returned when decrypting a frame whose key ID is unknown.
+var ErrUnknownKey = errors.New("log: unknown encryption key ID")
+
+type encryptedWriter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	id    byte
+	aead  cipher.AEAD
+	frame []byte
+}
+
+// NewEncryptedWriter returns a writer that encrypts the data of each call to
+// Write, typically one log entry, with AES-GCM and a random nonce, and writes
+// it to w as a single frame. key must be 16, 24 or 32 bytes long. keyID is
+// stored with every frame, so that files written with different keys, for
+// example before and after a key rotation, can be decrypted with a set of
+// keys. Use NewDecryptReader or Decrypt to decrypt the output.
+func NewEncryptedWriter(w io.Writer, keyID byte, key []byte) (io.Writer, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedWriter{w: w, id: keyID, aead: aead}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (e *encryptedWriter) Write(p []byte) (n int, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ns := e.aead.NonceSize()
+	frame := append(e.frame[:0], 0, 0, 0, 0, e.id)
+	frame = append(frame, make([]byte, ns)...)
+	nonce := frame[frameHeaderLen+1:]
+	if _, err = rand.Read(nonce); err != nil {
+		return 0, err
+	}
+	frame = e.aead.Seal(frame, nonce, p, frame[frameHeaderLen:frameHeaderLen+1])
+	binary.BigEndian.PutUint32(frame, uint32(len(frame)-frameHeaderLen))
+	e.frame = frame
+	if _, err = e.w.Write(frame); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+type decryptReader struct {
+	r     *bufio.Reader
+	keys  map[byte]cipher.AEAD
+	frame []byte
+	plain []byte
+	err   error
+}
+
+// NewDecryptReader returns a reader that decrypts the output of an encrypted
+// writer read from r, using the keys mapped by their key IDs. If the output is
+// truncated in the middle of a frame, the reader returns the data of all
+// complete frames, followed by io.ErrUnexpectedEOF.
+func NewDecryptReader(r io.Reader, keys map[byte][]byte) (io.Reader, error) {
+	d := &decryptReader{
+		r:    bufio.NewReader(r),
+		keys: make(map[byte]cipher.AEAD, len(keys)),
+	}
+	for id, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("log: key %d: %w", id, err)
+		}
+		d.keys[id] = aead
+	}
+	return d, nil
+}
+
+func (d *decryptReader) Read(p []byte) (n int, err error) {
+	for len(d.plain) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.err = d.next()
+	}
+	n = copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// next reads and decrypts the next frame.
+func (d *decryptReader) next() error {
+	var hdr [frameHeaderLen]byte
+	if _, err := io.ReadFull(d.r, hdr[:]); err != nil {
+		return err
+	}
+	size := binary.BigEndian.Uint32(hdr[:])
+	if size > maxFrameLen {
+		return errors.New("log: invalid encrypted frame")
+	}
+	if cap(d.frame) < int(size) {
+		d.frame = make([]byte, size)
+	}
+	frame := d.frame[:size]
+	if _, err := io.ReadFull(d.r, frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if len(frame) < 1 {
+		return errors.New("log: invalid encrypted frame")
+	}
+	aead, ok := d.keys[frame[0]]
+	if !ok {
+		return ErrUnknownKey
+	}
+	ns := aead.NonceSize()
+	if len(frame) < 1+ns {
+		return errors.New("log: invalid encrypted frame")
+	}
+	plain, err := aead.Open(frame[1+ns:1+ns], frame[1:1+ns], frame[1+ns:], frame[:1])
+	if err != nil {
+		return err
+	}
+	d.plain = plain
+	return nil
+}
+
+// Decrypt decrypts the output of an encrypted writer from src to dst. It can
+// serve as the body of a small command for decrypting log files offline:
+//
+//	func main() {
+//		key, _ := hex.DecodeString(os.Getenv("LOG_KEY"))
+//		err := log.Decrypt(os.Stdout, os.Stdin, map[byte][]byte{0: key})
+//		if err != nil {
+//			fmt.Fprintln(os.Stderr, err)
+//			os.Exit(1)
+//		}
+//	}
+func Decrypt(dst io.Writer, src io.Reader, keys map[byte][]byte) error {
+	r, err := NewDecryptReader(src, keys)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, r)
+	return err
+}
@@ -0,0 +1,84 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func encryptEntries(t *testing.T, keyID byte, key []byte, msgs ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewEncryptedWriter(&buf, keyID, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(w, "", 0)
+	for _, msg := range msgs {
+		l.Error(msg)
+	}
+	return buf.Bytes()
+}
+
+func TestEncryptedWriter(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 16)
+	data := encryptEntries(t, 1, oldKey, "one", "two")
+	if bytes.Contains(data, []byte("one")) {
+		t.Fatal("output contains the plain text")
+	}
+	// A file written before and after a key rotation.
+	data = append(data, encryptEntries(t, 2, newKey, "three")...)
+
+	var out bytes.Buffer
+	if err := Decrypt(&out, bytes.NewReader(data), map[byte][]byte{1: oldKey, 2: newKey}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "one\ntwo\nthree\n"; got != want {
+		t.Errorf("decrypted %q, want %q", got, want)
+	}
+
+	if _, err := NewEncryptedWriter(io.Discard, 0, []byte("short")); err == nil {
+		t.Error("NewEncryptedWriter accepted a 5 byte key")
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	data := encryptEntries(t, 3, key, "one", "two")
+	first := len(encryptEntries(t, 3, key, "one"))
+	keys := map[byte][]byte{3: key}
+
+	tampered := append([]byte(nil), data...)
+	tampered[len(tampered)-1] ^= 1
+	hugeFrame := append(append([]byte(nil), data[:first]...), 0xff, 0xff, 0xff, 0xff)
+	emptyFrame := append(append([]byte(nil), data[:first]...), 0, 0, 0, 0)
+	shortFrame := append(append([]byte(nil), data[:first]...), 0, 0, 0, 3, 3, 0, 0)
+
+	for _, c := range []struct {
+		name string
+		data []byte
+		keys map[byte][]byte
+		want string // decrypted before the error
+		err  error  // if not nil, the error wanted
+	}{
+		{"wrong key", data, map[byte][]byte{3: bytes.Repeat([]byte{9}, 32)}, "", nil},
+		{"unknown key ID", data, map[byte][]byte{4: key}, "", ErrUnknownKey},
+		{"truncated frame", data[:len(data)-3], keys, "one\n", io.ErrUnexpectedEOF},
+		{"truncated header", data[:first+2], keys, "one\n", io.ErrUnexpectedEOF},
+		{"tampered frame", tampered, keys, "one\n", nil},
+		{"huge frame", hugeFrame, keys, "one\n", nil},
+		{"empty frame", emptyFrame, keys, "one\n", nil},
+		{"short frame", shortFrame, keys, "one\n", nil},
+	} {
+		var out bytes.Buffer
+		err := Decrypt(&out, bytes.NewReader(c.data), c.keys)
+		if err == nil || (c.err != nil && !errors.Is(err, c.err)) {
+			t.Errorf("%s: Decrypt returned %v, want an error", c.name, err)
+		}
+		if out.String() != c.want {
+			t.Errorf("%s: decrypted %q before the error, want %q", c.name, out.String(), c.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package log
+
+import (
+	"context"
+	"time"
+)
+
+// An Entry describes a log entry.
+type Entry struct {
+	Level   int         // log level
+	Time    time.Time   // time the entry was logged
+	Prefix  string      // prefix of the logger
+	Name    string      // name of the logger, as by Named
+	File    string      // file name of the caller, if known
+	Line    int         // line number of the caller, if known
+	Message string      // message, without header and label
+	Code    string      // event or error code, as by Errorc
+	Fields  []Field     // global, dynamic, With, per-call and error fields, in that order
+	Err     error       // error being logged, as by Wrap
+	Panic   interface{} // recovered panic value, as by Recover
+	Stack   []byte      // stack of the panicking goroutine, as by Recover
+}
+
+// entryExtra holds details of the entry being logged that are not part of
+// its message.
+type entryExtra struct {
+	code   string
+	fields []Field
+	err    error
+	panic  interface{}
+	stack  []byte
+	ctx    context.Context // passed to a *Ctx method, if any
+}
+
+// context returns the context of the entry, or the background context if it
+// was not logged by a *Ctx method.
+func (e *entryExtra) context() context.Context {
+	if e.ctx != nil {
+		return e.ctx
+	}
+	return context.Background()
+}
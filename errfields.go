@@ -0,0 +1,124 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A LogFielder is an error that carries fields describing its context, like
+// the tenant or request it occurred in. When such an error is logged, as a
+// message argument or with Wrap or ErrE, its fields are added to the entry.
+// Wrapped errors are searched as well; if several errors in the chain provide a
+// field with the same key, the outermost one is used.
+//
+// For example:
+//
+//	type TenantError struct {
+//		Tenant string
+//		Err    error
+//	}
+//
+//	func (e *TenantError) Error() string { return e.Tenant + ": " + e.Err.Error() }
+//	func (e *TenantError) Unwrap() error { return e.Err }
+//
+//	func (e *TenantError) LogFields() []log.Field {
+//		return []log.Field{{"tenant", e.Tenant}}
+//	}
+type LogFielder interface {
+	LogFields() []Field
+}
+
+// maxErrorDepth is the maximum number of wrapped errors searched for fields.
+const maxErrorDepth = 100
+
+// collectErrorFields adds the fields of the errors among the message arguments
+// v to the entry being logged.
+func (l *Logger) collectErrorFields(v []interface{}) {
+	for _, x := range v {
+		if err, ok := x.(error); ok {
+			l.extra.fields = appendErrorFields(l.extra.fields, err)
+		}
+	}
+}
+
+// appendErrorFields appends the fields of err and the errors it wraps to f,
+// skipping keys that are already in f.
+func appendErrorFields(f []Field, err error) []Field {
+	queue := []error{err}
+	for n := 0; len(queue) > 0 && n < maxErrorDepth; n++ {
+		err, queue = queue[0], queue[1:]
+		if lf, ok := err.(LogFielder); ok {
+			f = appendNewFields(f, lf.LogFields())
+		}
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			if e := u.Unwrap(); e != nil {
+				queue = append(queue, e)
+			}
+		case interface{ Unwrap() []error }:
+			queue = append(queue, u.Unwrap()...)
+		}
+	}
+	return f
+}
+
+// appendNewFields appends the fields in add whose keys are not in f.
+func appendNewFields(f, add []Field) []Field {
+next:
+	for _, a := range add {
+		for _, field := range f {
+			if field.Key == a.Key {
+				continue next
+			}
+		}
+		f = append(f, a)
+	}
+	return f
+}
+
+// verboseErrors replaces the errors in v by their verbose form, if l has the
+// Lverboseerr flag. It only copies v if it contains errors.
+func (l *Logger) verboseErrors(v []interface{}) []interface{} {
+	if l.flag&Lverboseerr == 0 {
+		return v
+	}
+	var r []interface{}
+	for i, x := range v {
+		if err, ok := x.(error); ok {
+			if r == nil {
+				r = append([]interface{}(nil), v...)
+			}
+			r[i] = verboseError(err)
+		}
+	}
+	if r == nil {
+		return v
+	}
+	return r
+}
+
+// verboseError returns the verbose form of err: the %+v form if err implements
+// fmt.Formatter, like the errors of github.com/pkg/errors, which include a stack
+// trace. Otherwise it returns the messages of err and the errors it wraps,
+// joined by ": ", leaving out messages that are already part of the previous
+// one, as with errors wrapped by fmt.Errorf.
+func verboseError(err error) string {
+	if _, ok := err.(fmt.Formatter); ok {
+		return fmt.Sprintf("%+v", err)
+	}
+	var parts []string
+	prev := ""
+	for n := 0; err != nil && n < maxErrorDepth; n++ {
+		msg := err.Error()
+		if !strings.Contains(prev, msg) {
+			parts = append(parts, msg)
+			prev = msg
+		}
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		err = u.Unwrap()
+	}
+	return strings.Join(parts, ": ")
+}
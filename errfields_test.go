@@ -0,0 +1,118 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type tenantError struct {
+	tenant string
+	err    error
+}
+
+func (e *tenantError) Error() string { return e.tenant + ": " + e.err.Error() }
+func (e *tenantError) Unwrap() error { return e.err }
+
+func (e *tenantError) LogFields() []Field {
+	return []Field{{"tenant", e.tenant}}
+}
+
+func TestErrorFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	err := fmt.Errorf("loading: %w", &tenantError{"acme", errors.New("not found")})
+
+	l.Error("failed: ", err)
+	if got, want := buf.String(), "failed: loading: acme: not found tenant=acme\n"; got != want {
+		t.Errorf("Error wrote %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	l.Wrapf(errors.New("retry"), "after %v", err)
+	if got, want := buf.String(), "after loading: acme: not found: retry tenant=acme\n"; got != want {
+		t.Errorf("Wrapf wrote %q, want %q", got, want)
+	}
+
+	// The fields of one entry must not end up in the next.
+	buf.Reset()
+	l.Error("done")
+	if got, want := buf.String(), "done\n"; got != want {
+		t.Errorf("Error wrote %q, want %q", got, want)
+	}
+}
+
+type apiKey string
+
+func (apiKey) LogValue() interface{} { return "***" }
+
+func TestErrE(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	cause := &tenantError{"acme", errors.New("not found")}
+
+	err := l.ErrE("loading with key %s: %w (100%%w)", apiKey("s3cr3t"), cause)
+	if want := fmt.Errorf("loading with key %s: %w (100%%w)", apiKey("s3cr3t"), cause); err.Error() != want.Error() {
+		t.Errorf("ErrE returned %q, want %q", err, want)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("ErrE did not wrap the error")
+	}
+	if got, want := buf.String(), "loading with key ***: acme: not found (100%w) tenant=acme\n"; got != want {
+		t.Errorf("ErrE wrote %q, want %q", got, want)
+	}
+}
+
+func TestWrapfConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	err := &tenantError{"acme", errors.New("not found")}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Wrapf(errors.New("retry"), "after %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Error("plain")
+			}
+		}()
+	}
+	wg.Wait()
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if line == "plain tenant=acme" {
+			t.Fatalf("fields of a Wrapf entry were added to another entry")
+		}
+	}
+}
+
+func TestErrorFieldsSampled(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+	l.SetSampler(LevelError, SampleEvery(10))
+	err := &tenantError{"acme", errors.New("not found")}
+
+	// The summary is due while attempt 11, which is dropped, is logged.
+	for i := 0; i < 12; i++ {
+		l.Wrapf(errors.New("retry"), "attempt %d: %v", i, err)
+		now = now.Add(time.Second)
+	}
+	want := "attempt 0: acme: not found: retry tenant=acme\n" +
+		"attempt 10: acme: not found: retry tenant=acme\n" +
+		"suppressed 10 similar entries in the last 10s\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
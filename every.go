@@ -0,0 +1,94 @@
+package log
+
+import (
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// throttle limits the entries of each call site to one per interval.
+type throttle struct {
+	d     time.Duration
+	sites map[uintptr]*site
+	// lines maps call sites by file and line, since a call that is inlined
+	// in several places has several program counters.
+	lines map[fileLine]*site
+	// suppressed is the number of entries suppressed at the call site of the
+	// entry being logged, to be reported with it.
+	suppressed int
+}
+
+type fileLine struct {
+	file string
+	line int
+}
+
+type site struct {
+	last       time.Time
+	suppressed int
+}
+
+// Every returns a Logger that writes through l, but logs at most one entry per
+// interval d for each call site, silently dropping the others. The next entry
+// logged at a call site reports how many entries were dropped there, as a
+// suppressed=N field. Fatal and panic entries are never dropped.
+//
+// The returned Logger should be kept and reused, for example in a package
+// variable, since the throttling state belongs to it.
+func (l *Logger) Every(d time.Duration) *Logger {
+	e := &Logger{
+		logger: l.logger,
+		with:   l.with,
+		comp:   l.comp,
+		every: &throttle{
+			d:     d,
+			sites: make(map[uintptr]*site),
+			lines: make(map[fileLine]*site),
+		},
+	}
+	e.depth.Store(l.depth.Load())
+	return e
+}
+
+// allow reports whether an entry from the call site pc may be logged at time
+// now. It must be called with the mutex of the logger held.
+func (t *throttle) allow(pc uintptr, now time.Time) bool {
+	s := t.sites[pc]
+	if s == nil {
+		s = t.lookup(pc)
+	}
+	if !s.last.IsZero() && now.Sub(s.last) < t.d {
+		s.suppressed++
+		return false
+	}
+	s.last = now
+	t.suppressed = s.suppressed
+	s.suppressed = 0
+	return true
+}
+
+// lookup returns the site of the call at pc and caches it by pc.
+func (t *throttle) lookup(pc uintptr) *site {
+	var key fileLine
+	if f := runtime.FuncForPC(pc - 1); f != nil {
+		key.file, key.line = f.FileLine(pc - 1)
+	}
+	s := t.lines[key]
+	if s == nil {
+		s = new(site)
+		t.lines[key] = s
+	}
+	t.sites[pc] = s
+	return s
+}
+
+// appendSuppressed appends the suppressed count of the entry being logged to
+// s, if any, and resets it.
+func (t *throttle) appendSuppressed(s string) string {
+	if t.suppressed == 0 {
+		return s
+	}
+	n := t.suppressed
+	t.suppressed = 0
+	return appendMessage(s, " suppressed="+strconv.Itoa(n))
+}
@@ -0,0 +1,57 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestEvery(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+	e := l.Every(time.Second)
+	warn := func() { e.Errorln("disk full") }
+
+	for i := 0; i < 5; i++ {
+		warn()
+	}
+	e.Error("other call site")
+	now = now.Add(999 * time.Millisecond)
+	warn()
+	now = now.Add(time.Millisecond)
+	warn()
+	warn()
+	l.Error("not throttled")
+	l.Error("not throttled")
+	want := "disk full\nother call site\ndisk full suppressed=5\nnot throttled\nnot throttled\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	if n := testing.AllocsPerRun(100, warn); n != 0 {
+		t.Errorf("suppressed entry allocates %v times", n)
+	}
+}
+
+func TestEveryFatalPanic(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+	e := l.Every(time.Hour)
+	prev := SetExitFunc(func(int) {})
+	defer SetExitFunc(prev)
+
+	for i := 0; i < 3; i++ {
+		e.Fatal("fatal")
+		func() {
+			defer func() { recover() }()
+			e.Panic("panic")
+		}()
+	}
+	if got, want := buf.String(), "fatal\npanic\nfatal\npanic\nfatal\npanic\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
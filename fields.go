@@ -0,0 +1,221 @@
+package log
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Field is a named value attached to a log entry.
+//
+// Struct values are formatted as by fmt, unless their fields have log tags.
+// The log tag of a struct field holds comma-separated options: "-" leaves the
+// field out, "mask" shows its value as ***, and "name=key" shows it under key.
+// For example, a value of type
+//
+//	type Account struct {
+//		User     string `log:"name=user"`
+//		Password string `log:"-"`
+//		Token    string `log:"mask"`
+//	}
+//
+// is logged like {user:alice Token:***}. Nested and embedded structs, pointers
+// to them and slices of them are formatted by the same rules.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// group is the value of a field that consists of other fields, like the one
+// returned by Request.
+type group []Field
+
+// Fields is a set of named values attached to a log entry.
+type Fields map[string]interface{}
+
+// appendFields appends the fields in f whose names are not in skip to b, in
+// sorted order, as space-separated key=value pairs.
+func appendFields(b []byte, f Fields, skip map[string]bool, l *Logger) []byte {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		if !skip[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b = appendField(b, k, f[k], l)
+	}
+	return b
+}
+
+// appendFieldList appends the fields in f to b, in order, as space-separated
+// key=value pairs.
+func appendFieldList(b []byte, f []Field, l *Logger) []byte {
+	for _, field := range f {
+		b = appendField(b, field.Key, field.Value, l)
+	}
+	return b
+}
+
+// appendField appends a space followed by key=value to b, formatting the value
+// according to the settings of l. The value is quoted if it is empty or
+// contains characters that would make it ambiguous. The fields of a group are
+// appended one by one, with their keys prefixed by key and a dot.
+func appendField(b []byte, key string, value interface{}, l *Logger) []byte {
+	value = l.resolve(value)
+	if g, ok := value.(group); ok {
+		for _, f := range g {
+			b = appendField(b, key+"."+f.Key, f.Value, l)
+		}
+		return b
+	}
+	b = append(b, ' ')
+	b = append(b, key...)
+	b = append(b, '=')
+	s := l.fieldString(value)
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") {
+		return strconv.AppendQuote(b, s)
+	}
+	return append(b, s...)
+}
+
+// fieldString returns the text form of a field value. Values with a human
+// readable form, like those of Bytes and Dur fields, are rendered in that form
+// if l is human readable, and as a plain number otherwise. Structs are rendered
+// according to the log tags of their fields, if they have any.
+func (l *Logger) fieldString(value interface{}) string {
+	value = l.resolve(value)
+	if s, ok := redactString(value); ok {
+		return s
+	}
+	if g, ok := value.(group); ok {
+		return strings.TrimPrefix(string(appendFieldList(nil, g, l)), " ")
+	}
+	if h, ok := value.(humanizer); ok {
+		if l.human {
+			return h.human()
+		}
+		value = h.raw()
+	}
+	if err, ok := value.(error); ok && l.flag&Lverboseerr != 0 {
+		return l.sprintFunc(func() string { return verboseError(err) })
+	}
+	return l.sprintValue(value)
+}
+
+// SetGlobalFields sets fields that are appended to the message of every entry
+// as key=value pairs, by l and by all loggers derived from it.
+func (l *Logger) SetGlobalFields(fields ...Field) {
+	l.mu.Lock()
+	l.global = append([]Field(nil), fields...)
+	l.mu.Unlock()
+}
+
+// AddGlobalFields adds fields to the global fields of l.
+func (l *Logger) AddGlobalFields(fields ...Field) {
+	l.mu.Lock()
+	l.global = append(l.global[:len(l.global):len(l.global)], fields...)
+	l.mu.Unlock()
+}
+
+func SetGlobalFields(fields ...Field) {
+	std.SetGlobalFields(fields...)
+}
+
+func AddGlobalFields(fields ...Field) {
+	std.AddGlobalFields(fields...)
+}
+
+// maxDynamicFields is the maximum number of dynamic fields of a logger.
+const maxDynamicFields = 16
+
+// A dynamicField is a field whose value is computed for every entry.
+type dynamicField struct {
+	key string
+	fn  func() interface{}
+}
+
+// AddDynamicField adds a field whose value is computed by calling fn for every
+// entry that is logged, and appended to its message as a key=value pair. It
+// replaces a dynamic field with the same key. fn runs while logging, with the
+// logger locked, so it must be fast and must not use the logger. If fn panics
+// the value is replaced by a placeholder. A logger can have at most 16
+// dynamic fields.
+func (l *Logger) AddDynamicField(key string, fn func() interface{}) {
+	defer l.unlock(l.lock())
+	for i, f := range l.dyn {
+		if f.key == key {
+			l.dyn[i].fn = fn
+			return
+		}
+	}
+	if len(l.dyn) >= maxDynamicFields {
+		panic("too many dynamic fields")
+	}
+	l.dyn = append(l.dyn, dynamicField{key, fn})
+}
+
+// RemoveDynamicField removes the dynamic field with the given key.
+func (l *Logger) RemoveDynamicField(key string) {
+	defer l.unlock(l.lock())
+	for i, f := range l.dyn {
+		if f.key == key {
+			l.dyn = append(l.dyn[:i], l.dyn[i+1:]...)
+			return
+		}
+	}
+}
+
+func AddDynamicField(key string, fn func() interface{}) {
+	std.AddDynamicField(key, fn)
+}
+
+func RemoveDynamicField(key string) {
+	std.RemoveDynamicField(key)
+}
+
+// fields returns the global fields of l, followed by its dynamic fields with
+// their current values.
+func (l *Logger) fields() []Field {
+	f := make([]Field, 0, len(l.global)+len(l.dyn))
+	f = append(f, l.global...)
+	for _, d := range l.dyn {
+		f = append(f, Field{d.key, l.callDynamic(d.key, d.fn)})
+	}
+	return f
+}
+
+// entryFields returns the fields of the entry being logged: the global and
+// dynamic fields of l and the pairs added by With, followed by those passed to
+// the logging method and those of the errors being logged.
+func (l *Logger) entryFields(extra entryExtra) []Field {
+	var f []Field
+	if len(l.global) > 0 || len(l.dyn) > 0 {
+		f = l.fields()
+	}
+	f = append(f, l.with...)
+	if extra.err != nil {
+		extra.fields = appendErrorFields(extra.fields, extra.err)
+	}
+	return append(f, extra.fields...)
+}
+
+func (l *Logger) callDynamic(key string, fn func() interface{}) (v interface{}) {
+	defer func() {
+		if p := recover(); p != nil {
+			v = "!PANIC(" + describePanic(p) + ")"
+			l.internalf("recovered from panic in dynamic field %s: %s", key, v)
+		}
+	}()
+	return fn()
+}
+
+// appendMessage appends extra to message s, before its trailing newline if it
+// has one.
+func appendMessage(s, extra string) string {
+	if strings.HasSuffix(s, "\n") {
+		return s[:len(s)-1] + extra + "\n"
+	}
+	return s + extra
+}
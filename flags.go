@@ -0,0 +1,146 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// flagNames contains the names of the flags, in the order of their constants.
+var flagNames = []struct {
+	name string
+	flag int
+}{
+	{"date", Ldate},
+	{"time", Ltime},
+	{"microseconds", Lmicroseconds},
+	{"longfile", Llongfile},
+	{"shortfile", Lshortfile},
+	{"utc", LUTC},
+	{"label", Llabel},
+	{"color", Lcolor},
+	{"quote", Lquote},
+	{"singleline", Lsingleline},
+	{"delta", Ldelta},
+	{"verboseerr", Lverboseerr},
+	{"json", LJSON},
+	{"colorauto", LcolorAuto},
+	{"logfmt", Llogfmt},
+}
+
+// levelNames contains the names of the log levels accepted by ParseLevel.
+var levelNames = map[string]int{
+	"fatal":   LevelFatal,
+	"panic":   LevelPanic,
+	"error":   LevelError,
+	"warn":    LevelWarn,
+	"warning": LevelWarn,
+	"info":    LevelInfo,
+	"debug":   LevelDebug,
+}
+
+// ParseLevel parses a log level name, such as "debug" or "warning", or a
+// numeric log level. Names are case-insensitive; the labels of levels
+// registered with RegisterLevel are accepted too.
+func ParseLevel(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if level, ok := levelNames[s]; ok {
+		return level, nil
+	}
+	if level := labelLevel(strings.ToUpper(s)); level >= 0 {
+		return level, nil
+	}
+	if level, err := strconv.Atoi(s); err == nil && validLevel(level) {
+		return level, nil
+	}
+	return 0, fmt.Errorf("log: unknown level %q", s)
+}
+
+// LevelString returns the name of level as accepted by ParseLevel, such as
+// "warn", or its number if it is not registered.
+func LevelString(level int) string {
+	return strings.ToLower(levelName(level))
+}
+
+// ParseFlags parses a comma-separated list of flag names, such as
+// "date,time,shortfile,label", and returns the flags or'ed together. Names are
+// case-insensitive and correspond to the flag constants without their L
+// prefix; "std" stands for LstdFlags.
+func ParseFlags(s string) (int, error) {
+	flag := 0
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name == "std" {
+			flag |= LstdFlags
+			continue
+		}
+		f, ok := lookupFlag(name)
+		if !ok {
+			return 0, fmt.Errorf("log: unknown flag %q (valid flags: %s,std)", name, FlagsString(^0))
+		}
+		flag |= f
+	}
+	return flag, nil
+}
+
+// FlagsString returns the names of the flags set in flag as a comma-separated
+// list, in the format accepted by ParseFlags.
+func FlagsString(flag int) string {
+	var names []string
+	for _, f := range flagNames {
+		if flag&f.flag != 0 {
+			names = append(names, f.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func lookupFlag(name string) (int, bool) {
+	for _, f := range flagNames {
+		if f.name == name {
+			return f.flag, true
+		}
+	}
+	return 0, false
+}
+
+// ConfigureFromEnv configures l from the environment variables LOG_LEVEL, as
+// accepted by ParseLevel, LOG_FLAGS, as accepted by ParseFlags, and
+// LOG_PREFIX. Variables that are not set or empty leave the corresponding
+// setting unchanged. If a value is invalid, an error is returned and l is not
+// changed at all.
+func (l *Logger) ConfigureFromEnv() error {
+	level, flag := -1, -1
+	if s := os.Getenv("LOG_LEVEL"); s != "" {
+		var err error
+		if level, err = ParseLevel(s); err != nil {
+			return fmt.Errorf("%w in LOG_LEVEL", err)
+		}
+	}
+	if s := os.Getenv("LOG_FLAGS"); s != "" {
+		var err error
+		if flag, err = ParseFlags(s); err != nil {
+			return fmt.Errorf("%w in LOG_FLAGS", err)
+		}
+	}
+	if level >= 0 {
+		l.SetLevel(level)
+	}
+	if flag >= 0 {
+		l.SetFlags(flag)
+	}
+	if s := os.Getenv("LOG_PREFIX"); s != "" {
+		l.SetPrefix(s)
+	}
+	return nil
+}
+
+// ConfigureFromEnv configures the standard logger from the environment, as
+// described for (*Logger).ConfigureFromEnv.
+func ConfigureFromEnv() error {
+	return std.ConfigureFromEnv()
+}
@@ -0,0 +1,199 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// HealthStatus describes whether a Logger has been able to write its entries.
+// The counters cover the output of the logger and the outputs set with
+// SetLevelOutput; Outputs breaks them down by output, and includes the outputs
+// added with AddOutput.
+type HealthStatus struct {
+	LastError      error     // error of the last failed write, or nil
+	LastErrorTime  time.Time // time of the last failed write
+	FailingSince   time.Time // time of the first of the current run of failed writes; zero if the last write succeeded
+	FailedWrites   uint64    // number of failed writes
+	DroppedEntries uint64    // number of entries dropped by outputs, see OutputHealth
+
+	Outputs []OutputHealth
+}
+
+// OutputHealth describes the write health of a single output of a Logger, as
+// part of a HealthStatus. DroppedEntries is only reported by outputs that
+// queue entries and drop them when they fall behind, like an AsyncWriter; it
+// counts the entries they dropped since they were created.
+type OutputHealth struct {
+	Output         io.Writer
+	LastError      error
+	LastErrorTime  time.Time
+	FailingSince   time.Time
+	FailedWrites   uint64
+	DroppedEntries uint64
+}
+
+// dropper is an output that counts the entries it dropped, like an
+// AsyncWriter.
+type dropper interface {
+	Dropped() int64
+}
+
+// An attacher is an output that is attached to the logger using it, to report
+// its problems on the internal output, like a RotatingFile.
+type attacher interface {
+	attach(l *Logger)
+}
+
+// A backgroundWriter is an output that writes entries after they were passed
+// to it, like an AsyncWriter. It is an attacher, and the health of its own
+// writes is part of the health of the output.
+type backgroundWriter interface {
+	attacher
+	writeHealth() *health
+}
+
+// output is an output of a logger, with its write health.
+type output struct {
+	w io.Writer
+	h *health
+}
+
+// updateOutputs updates the outputs listed by Health. It must be called with
+// l.mu held, or while l is not yet in use, after changing the outputs of l.
+func (l *Logger) updateOutputs() {
+	outs := []output{{l.cw.w, &l.cw.health}}
+	for _, rw := range l.route {
+		if rw != nil {
+			outs = append(outs, output{rw.w, &rw.health})
+		}
+	}
+	for _, t := range l.tees {
+		outs = append(outs, output{t.w, &t.health})
+	}
+	for _, o := range outs {
+		if a, ok := o.w.(attacher); ok {
+			a.attach(l)
+		}
+	}
+	l.outs.Store(&outs)
+}
+
+// health tracks write failures. It is updated without locking, so that taking
+// a snapshot never blocks writers.
+type health struct {
+	failed       atomic.Uint64
+	failingSince atomic.Int64 // Unix nanoseconds
+	last         atomic.Pointer[writeError]
+}
+
+type writeError struct {
+	err error
+	t   time.Time
+}
+
+func (h *health) record(err error) {
+	if err == nil {
+		if h.failingSince.Load() != 0 {
+			h.failingSince.Store(0)
+		}
+		return
+	}
+	now := time.Now()
+	h.failed.Add(1)
+	h.failingSince.CompareAndSwap(0, now.UnixNano())
+	h.last.Store(&writeError{err, now})
+}
+
+// merge adds the failures of o to s.
+func (s *OutputHealth) merge(o OutputHealth) {
+	s.FailedWrites += o.FailedWrites
+	if o.LastErrorTime.After(s.LastErrorTime) {
+		s.LastError, s.LastErrorTime = o.LastError, o.LastErrorTime
+	}
+	if !o.FailingSince.IsZero() && (s.FailingSince.IsZero() || o.FailingSince.Before(s.FailingSince)) {
+		s.FailingSince = o.FailingSince
+	}
+}
+
+// status returns a snapshot of h.
+func (h *health) status() (s OutputHealth) {
+	s.FailedWrites = h.failed.Load()
+	if since := h.failingSince.Load(); since != 0 {
+		s.FailingSince = time.Unix(0, since)
+	}
+	if last := h.last.Load(); last != nil {
+		s.LastError = last.err
+		s.LastErrorTime = last.t
+	}
+	return s
+}
+
+func (h *health) reset() {
+	h.failed.Store(0)
+	h.failingSince.Store(0)
+	h.last.Store(nil)
+}
+
+// Health returns a snapshot of the write health of the logger. It does not
+// lock the logger, so it never waits for entries being written.
+func (l *Logger) Health() HealthStatus {
+	total := l.health.status()
+	var s HealthStatus
+	if outs := l.outs.Load(); outs != nil {
+		for _, o := range *outs {
+			oh := o.h.status()
+			oh.Output = o.w
+			if bw, ok := o.w.(backgroundWriter); ok {
+				bh := bw.writeHealth().status()
+				oh.merge(bh)
+				total.merge(bh)
+			}
+			if d, ok := o.w.(dropper); ok {
+				oh.DroppedEntries = uint64(d.Dropped())
+			}
+			s.DroppedEntries += oh.DroppedEntries
+			s.Outputs = append(s.Outputs, oh)
+		}
+	}
+	s.LastError = total.LastError
+	s.LastErrorTime = total.LastErrorTime
+	s.FailingSince = total.FailingSince
+	s.FailedWrites = total.FailedWrites
+	return s
+}
+
+// ResetHealth clears the write health of the logger and its outputs. The
+// entries dropped by outputs are counted by the outputs themselves, and are not
+// reset.
+func (l *Logger) ResetHealth() {
+	l.health.reset()
+	if outs := l.outs.Load(); outs != nil {
+		for _, o := range *outs {
+			o.h.reset()
+			if bw, ok := o.w.(backgroundWriter); ok {
+				bw.writeHealth().reset()
+			}
+		}
+	}
+}
+
+// HealthHandler returns an HTTP handler that reports the write health of l. It
+// responds with 503 Service Unavailable if writes have been failing for longer
+// than maxFailing, and with 200 OK otherwise.
+//
+//	http.Handle("/healthz/logging", log.HealthHandler(logger, time.Minute))
+func HealthHandler(l *Logger, maxFailing time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := l.Health()
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if !s.FailingSince.IsZero() && time.Since(s.FailingSince) > maxFailing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, "log writes failing since %s: %v\n", s.FailingSince.Format(time.RFC3339), s.LastError)
+			return
+		}
+		fmt.Fprintf(w, "ok (%d failed writes)\n", s.FailedWrites)
+	})
+}
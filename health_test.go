@@ -0,0 +1,75 @@
+package log
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealth(t *testing.T) {
+	var errs bytes.Buffer
+	l := New(failingWriter{}, "", 0)
+	l.SetInternalOutput(&bytes.Buffer{})
+	l.SetLevelOutput(LevelWarn, &errs)
+	var extra bytes.Buffer
+	l.AddOutput(&extra, 0)
+
+	l.Error("lost")
+	l.Warn("written")
+	s := l.Health()
+	if s.FailedWrites != 1 || s.LastError == nil || s.FailingSince.IsZero() {
+		t.Errorf("status = %+v, want 1 failed write", s)
+	}
+	if len(s.Outputs) != 3 {
+		t.Fatalf("%d outputs, want 3", len(s.Outputs))
+	}
+	if o := s.Outputs[0]; o.Output != (failingWriter{}) || o.FailedWrites != 1 || o.LastError == nil {
+		t.Errorf("output = %+v, want 1 failed write", o)
+	}
+	for _, o := range s.Outputs[1:] {
+		if o.FailedWrites != 0 || o.LastError != nil {
+			t.Errorf("output %v: %d failed writes, last error %v", o.Output, o.FailedWrites, o.LastError)
+		}
+	}
+
+	l.ResetHealth()
+	if s := l.Health(); s.FailedWrites != 0 || s.Outputs[0].FailedWrites != 0 {
+		t.Errorf("status after ResetHealth = %+v", s)
+	}
+}
+
+func TestHealthDroppedEntries(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	aw := NewAsyncWriter(w, 1, OverflowDropOldest)
+	l := New(aw, "", 0)
+	for i := 0; i < 10; i++ {
+		l.Error("entry")
+	}
+	s := l.Health()
+	close(w.release)
+	aw.Close()
+	if s.DroppedEntries == 0 || s.DroppedEntries != s.Outputs[0].DroppedEntries {
+		t.Errorf("DroppedEntries = %d, output reports %d", s.DroppedEntries, s.Outputs[0].DroppedEntries)
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	l := New(failingWriter{}, "", 0)
+	l.SetInternalOutput(&bytes.Buffer{})
+	h := HealthHandler(l, time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status before failures = %d, want 200", rec.Code)
+	}
+	l.Error("lost")
+	time.Sleep(5 * time.Millisecond)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status while failing = %d, want 503", rec.Code)
+	}
+}
@@ -0,0 +1,58 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// StartHeartbeat logs a "heartbeat" entry at info level every interval, to show
+// that the program is alive and its logging works. The entry has an uptime
+// field, the time since StartHeartbeat was called, followed by the fields
+// returned by fn, if fn is not nil. A beat is skipped if other entries were
+// written since the previous one, as those already show the same. The returned
+// function stops the heartbeat.
+func (l *Logger) StartHeartbeat(interval time.Duration, fn func() []Field) (stop func()) {
+	start := l.now()
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		mark := l.stats.total()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				mark = l.heartbeat(start, mark, fn)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-stopped
+	}
+}
+
+func StartHeartbeat(interval time.Duration, fn func() []Field) (stop func()) {
+	return std.StartHeartbeat(interval, fn)
+}
+
+// heartbeat logs a heartbeat entry, unless the number of written entries
+// differs from mark. It returns the number of written entries after the beat.
+func (l *Logger) heartbeat(start time.Time, mark uint64, fn func() []Field) uint64 {
+	if n := l.stats.total(); n != mark {
+		return n
+	}
+	f := []Field{Dur("uptime", l.now().Sub(start))}
+	if fn != nil {
+		f = append(f, fn()...)
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelInfo) {
+		l.format(LevelInfo, string(appendFieldList([]byte("heartbeat"), f, l)))
+	}
+	return l.stats.total()
+}
@@ -0,0 +1,61 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	l.SetLevel(LevelInfo)
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+	start := now
+	processed := 0
+	fields := func() []Field { return []Field{{"processed", processed}} }
+
+	// The beats are driven by hand, as the ticker of StartHeartbeat would.
+	now = now.Add(time.Second)
+	mark := l.heartbeat(start, l.stats.total(), fields)
+	processed = 5
+	now = now.Add(time.Second)
+	l.Info("working")
+	mark = l.heartbeat(start, mark, fields) // skipped
+	now = now.Add(time.Second)
+	mark = l.heartbeat(start, mark, fields)
+	l.SetHumanReadable(true)
+	now = now.Add(time.Minute)
+	l.heartbeat(start, mark, nil)
+
+	want := "heartbeat uptime=1000 processed=0\n" +
+		"working\n" +
+		"heartbeat uptime=3000 processed=5\n" +
+		"heartbeat uptime=1m3s\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestStartHeartbeat(t *testing.T) {
+	var buf syncBuffer
+	l := New(&buf, "", 0)
+	l.SetLevel(LevelInfo)
+	stop := l.StartHeartbeat(time.Millisecond, nil)
+	deadline := time.Now().Add(5 * time.Second)
+	for strings.Count(buf.String(), "heartbeat") < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	stop()
+	n := strings.Count(buf.String(), "heartbeat")
+	if n < 2 {
+		t.Fatalf("%d heartbeats logged, want at least 2", n)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if got := strings.Count(buf.String(), "heartbeat"); got != n {
+		t.Errorf("%d heartbeats logged after stop", got-n)
+	}
+}
@@ -0,0 +1,357 @@
+package log
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A Hook is called with the entries logged at or above the level it was added
+// with. See (*Logger).AddHook.
+type Hook func(e Entry)
+
+// A HookE is like a Hook, but reports whether it failed, see AddHookE.
+type HookE func(e Entry) error
+
+// hook is a Hook or HookE added to a logger. A nil queue means it is called
+// directly.
+type hook struct {
+	fn      HookE
+	orig    Hook // if added as a Hook
+	min     int
+	prio    int
+	max     int    // consecutive failures after which the hook is disabled, or 0
+	levels  uint32 // bit set of the levels the hook is called at, or 0 for min
+	filter  func(*Entry) bool
+	queue   chan Entry
+	refs    atomic.Int32 // number of loggers using queue, see Clone
+	pending sync.WaitGroup
+
+	failures    atomic.Uint64
+	consecutive atomic.Int64
+	disabled    atomic.Bool
+}
+
+// HookStat describes a hook of a Logger, see HookStats.
+type HookStat struct {
+	Priority int    // priority, see WithPriority
+	Failures uint64 // number of calls that returned an error or panicked
+	Disabled bool   // disabled after too many failures, see WithMaxFailures
+}
+
+// A HookOption configures a hook added with AddHook or AddAsyncHook.
+type HookOption func(*hook)
+
+// WithPriority sets the priority of a hook. Hooks are called in order of
+// priority, lower first, and in the order they were added within the same
+// priority; a hook that redacts entries, for example, can be run before one
+// that exports them. Asynchronous hooks are passed the entry in the same
+// order, but as they run on their own goroutines, they may still be called in
+// another order. The default priority is 0.
+func WithPriority(p int) HookOption {
+	return func(hk *hook) { hk.prio = p }
+}
+
+// WithMaxFailures disables a hook after it failed n times in a row, by
+// returning an error or panicking, so that a hook whose destination is down
+// stops costing time. Disabling the hook is reported on the internal output,
+// and by HookStats. The default, 0, never disables a hook.
+func WithMaxFailures(n int) HookOption {
+	return func(hk *hook) { hk.max = n }
+}
+
+// WithHookLevels calls a hook with the entries logged at exactly the given
+// levels, instead of at the level it was added with or above; a hook paging
+// someone, for example, may only want LevelFatal and LevelPanic. It panics if
+// a level is not between 0 and MaxLevel.
+func WithHookLevels(levels ...int) HookOption {
+	var set uint32
+	for _, level := range levels {
+		if level < 0 || level > MaxLevel {
+			panic("log: hook level out of range")
+		}
+		set |= 1 << level
+	}
+	return func(hk *hook) { hk.levels = set }
+}
+
+// WithHookFilter calls a hook only with the entries for which f returns true.
+// f is called while the logger is locked, before an entry is queued for an
+// asynchronous hook, so that the entries it rejects cost no copy; it must not
+// modify the entry. A panic in f is recovered and reported on the internal
+// output, and the entry is not passed to the hook.
+func WithHookFilter(f func(e *Entry) bool) HookOption {
+	return func(hk *hook) { hk.filter = f }
+}
+
+// AddHook adds h to be called with each entry logged by l at level min or
+// above, after the entry is written, or failed to be written, to the output.
+// The File and Line of the entry are only set if the flags of l include
+// Lshortfile or Llongfile. The returned function removes the hook. Hooks are
+// called in order of priority, see WithPriority.
+//
+// The hook is called while the logger is locked, so it should return quickly.
+// Entries that it logs through l are dropped and reported on the internal
+// output, rather than deadlocking; a hook that needs to log, or that is slow,
+// like one posting to a webhook, should be added with AddAsyncHook instead. A
+// panic in the hook is recovered and reported on the internal output.
+func (l *Logger) AddHook(min int, h Hook, opts ...HookOption) (remove func()) {
+	return l.AddHookE(min, ignoreError(h), append(opts, original(h))...)
+}
+
+// AddHookE is like AddHook, but h reports its failures by returning an error.
+// Hook errors never keep an entry from the outputs, as hooks are called after
+// the entry is written. Each failure, including a panic in h, is written to
+// the internal output and counted, see HookStats; with WithMaxFailures, the
+// hook is disabled after a number of failures in a row.
+func (l *Logger) AddHookE(min int, h HookE, opts ...HookOption) (remove func()) {
+	hk := &hook{fn: h, min: min}
+	for _, opt := range opts {
+		opt(hk)
+	}
+	return l.addHook(hk)
+}
+
+// AddAsyncHook is like AddHook, but h is called from a separate goroutine,
+// so that it does not delay logging and may log through l itself. At most
+// queue entries wait to be passed to h; when it falls further behind, new
+// entries are dropped, which is reported on the internal output. Before a
+// fatal entry exits the program, the queued entries are passed to h, for at
+// most a few seconds.
+func (l *Logger) AddAsyncHook(min int, h Hook, queue int, opts ...HookOption) (remove func()) {
+	return l.AddAsyncHookE(min, ignoreError(h), queue, append(opts, original(h))...)
+}
+
+// AddAsyncHookE is like AddAsyncHook, but h reports its failures by returning
+// an error, as described for AddHookE.
+func (l *Logger) AddAsyncHookE(min int, h HookE, queue int, opts ...HookOption) (remove func()) {
+	hk := &hook{fn: h, min: min, queue: make(chan Entry, queue)}
+	for _, opt := range opts {
+		opt(hk)
+	}
+	l.startAsyncHook(hk)
+	return l.addHook(hk)
+}
+
+// startAsyncHook starts the goroutine that calls the asynchronous hook hk,
+// which stops when its queue is closed.
+func (l *Logger) startAsyncHook(hk *hook) {
+	hk.refs.Store(1)
+	go func() {
+		for e := range hk.queue {
+			l.callHook(hk, e)
+			hk.pending.Done()
+		}
+	}()
+}
+
+func AddHook(min int, h Hook, opts ...HookOption) (remove func()) {
+	return std.AddHook(min, h, opts...)
+}
+
+func AddAsyncHook(min int, h Hook, queue int, opts ...HookOption) (remove func()) {
+	return std.AddAsyncHook(min, h, queue, opts...)
+}
+
+func AddHookE(min int, h HookE, opts ...HookOption) (remove func()) {
+	return std.AddHookE(min, h, opts...)
+}
+
+func AddAsyncHookE(min int, h HookE, queue int, opts ...HookOption) (remove func()) {
+	return std.AddAsyncHookE(min, h, queue, opts...)
+}
+
+// ignoreError returns h as a HookE that never fails.
+func ignoreError(h Hook) HookE {
+	return func(e Entry) error {
+		h(e)
+		return nil
+	}
+}
+
+// original records h as the Hook that was added, to be returned by Hooks.
+func original(h Hook) HookOption {
+	return func(hk *hook) { hk.orig = h }
+}
+
+// Hooks returns the hooks of l, in the order in which they are called.
+func (l *Logger) Hooks() []Hook {
+	defer l.unlock(l.lock())
+	hooks := make([]Hook, len(l.hooks))
+	for i, hk := range l.hooks {
+		if hooks[i] = hk.orig; hooks[i] == nil {
+			fn := hk.fn
+			hooks[i] = func(e Entry) { fn(e) }
+		}
+	}
+	return hooks
+}
+
+// HookStats returns the statistics of the hooks of l, in the same order as
+// Hooks.
+func (l *Logger) HookStats() []HookStat {
+	defer l.unlock(l.lock())
+	stats := make([]HookStat, len(l.hooks))
+	for i, hk := range l.hooks {
+		stats[i] = HookStat{
+			Priority: hk.prio,
+			Failures: hk.failures.Load(),
+			Disabled: hk.disabled.Load(),
+		}
+	}
+	return stats
+}
+
+func Hooks() []Hook {
+	return std.Hooks()
+}
+
+func HookStats() []HookStat {
+	return std.HookStats()
+}
+
+func (l *Logger) addHook(hk *hook) (remove func()) {
+	defer l.unlock(l.lock())
+	// Insert hk after the hooks with the same or a lower priority.
+	i := len(l.hooks)
+	for i > 0 && l.hooks[i-1].prio > hk.prio {
+		i--
+	}
+	hooks := make([]*hook, 0, len(l.hooks)+1)
+	hooks = append(append(append(hooks, l.hooks[:i]...), hk), l.hooks[i:]...)
+	l.hooks = hooks
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			defer l.unlock(l.lock())
+			hooks := make([]*hook, 0, len(l.hooks))
+			for _, h := range l.hooks {
+				if h != hk {
+					hooks = append(hooks, h)
+				}
+			}
+			l.hooks = hooks
+			if hk.queue != nil && hk.refs.Add(-1) == 0 {
+				close(hk.queue)
+			}
+		})
+	}
+}
+
+// runHooks passes an entry to the hooks of l. It must be called directly by
+// format.
+func (l *Logger) runHooks(level int, s string, fields []Field, extra entryExtra) {
+	var e Entry
+	built := false
+	for _, hk := range l.hooks {
+		if !hk.wants(level) || hk.disabled.Load() {
+			continue
+		}
+		if !built {
+			e = l.hookEntry(level, s, fields, extra)
+			built = true
+		}
+		if hk.filter != nil && !l.filterHook(hk, &e) {
+			continue
+		}
+		if hk.queue == nil {
+			l.callHook(hk, e)
+			continue
+		}
+		hk.pending.Add(1)
+		select {
+		case hk.queue <- e:
+		default:
+			hk.pending.Done()
+			l.internalf("hook queue is full, entry dropped")
+		}
+	}
+}
+
+// wants reports whether hk is called with the entries logged at level.
+func (hk *hook) wants(level int) bool {
+	if hk.levels != 0 {
+		return level >= 0 && level <= MaxLevel && hk.levels&(1<<level) != 0
+	}
+	return level <= hk.min
+}
+
+// filterHook reports whether the filter of hk accepts e.
+func (l *Logger) filterHook(hk *hook, e *Entry) (ok bool) {
+	defer func() {
+		if p := recover(); p != nil {
+			l.internalf("recovered from panic in hook filter: %s", describePanic(p))
+			ok = false
+		}
+	}()
+	return hk.filter(e)
+}
+
+// hookEntry returns the Entry passed to hooks. It must be called by runHooks.
+func (l *Logger) hookEntry(level int, s string, fields []Field, extra entryExtra) Entry {
+	e := Entry{
+		Level:   level,
+		Time:    l.now(),
+		Prefix:  l.Prefix(),
+		Name:    l.name(),
+		Message: strings.TrimSuffix(s, "\n"),
+		Code:    extra.code,
+		Fields:  fields,
+		Err:     extra.err,
+		Panic:   extra.panic,
+		Stack:   extra.stack,
+	}
+	if l.flag&(Lshortfile|Llongfile) != 0 {
+		// Skip hookEntry, runHooks, format and the logging method.
+		_, e.File, e.Line, _ = runtime.Caller(4 + l.skip)
+		if l.flag&Lshortfile != 0 {
+			e.File = filepath.Base(e.File)
+		}
+	}
+	return e
+}
+
+// callHook calls hk with e, and records whether it failed.
+func (l *Logger) callHook(hk *hook, e Entry) {
+	failed := true
+	defer func() {
+		if p := recover(); p != nil {
+			l.internalf("recovered from panic in hook: %s", describePanic(p))
+		}
+		if !failed {
+			hk.consecutive.Store(0)
+			return
+		}
+		hk.failures.Add(1)
+		if n := hk.consecutive.Add(1); hk.max > 0 && n >= int64(hk.max) && hk.disabled.CompareAndSwap(false, true) {
+			l.internalf("hook disabled after %d consecutive failures", n)
+		}
+	}()
+	if err := hk.fn(e); err != nil {
+		l.internalf("hook failed: %v", err)
+		return
+	}
+	failed = false
+}
+
+// flushHooks waits for the queued entries of the asynchronous hooks to be
+// passed to them, for at most a few seconds.
+func flushHooks(hooks []*hook) {
+	if len(hooks) == 0 {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		for _, hk := range hooks {
+			hk.pending.Wait()
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+	}
+}
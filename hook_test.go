@@ -0,0 +1,166 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	var b, diag bytes.Buffer
+	l := New(&b, "p ", Lshortfile|Llabel)
+	l.SetInternalOutput(&diag)
+	l.SetLevel(LevelInfo)
+	var got []Entry
+	remove := l.AddHook(LevelWarn, func(e Entry) {
+		got = append(got, e)
+		l.Error("from hook")
+	})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var async Entry
+	removeAsync := l.AddAsyncHook(LevelError, func(e Entry) {
+		async = e
+		l.Info("async ok")
+		wg.Done()
+	}, 4)
+	l.Info("skip")
+	_, _, line, _ := runtime.Caller(0)
+	l.With("k", 1).Error("boom")
+	wg.Wait()
+	remove()
+	removeAsync()
+	remove()
+	l.Error("after")
+
+	if len(got) != 1 || got[0].Message != "boom k=1" || got[0].File != "hook_test.go" || got[0].Line != line+1 || got[0].Prefix != "p " {
+		t.Errorf("hook got %+v", got)
+	}
+	if async.Message != "boom k=1" || !strings.Contains(b.String(), "async ok") {
+		t.Errorf("async hook got %+v, output %q", async, b.String())
+	}
+	if !strings.Contains(diag.String(), "recursive log call suppressed: from hook") {
+		t.Errorf("internal output = %q", diag.String())
+	}
+}
+
+func TestHookPriority(t *testing.T) {
+	l := New(&bytes.Buffer{}, "", 0)
+	var order []string
+	hook := func(name string) Hook {
+		return func(e Entry) { order = append(order, name) }
+	}
+	l.AddHook(LevelError, hook("export"), WithPriority(10))
+	l.AddHook(LevelError, hook("metrics"))
+	l.AddHook(LevelError, hook("redact"), WithPriority(-1))
+	remove := l.AddHook(LevelError, hook("audit"), WithPriority(10))
+	l.AddHook(LevelError, hook("alert"), WithPriority(10))
+	remove()
+
+	l.Error("x")
+	if got, want := strings.Join(order, ","), "redact,metrics,export,alert"; got != want {
+		t.Errorf("hooks called in order %s, want %s", got, want)
+	}
+	if n := len(l.Hooks()); n != 4 {
+		t.Errorf("Hooks returned %d hooks, want 4", n)
+	}
+}
+
+func TestHookErrors(t *testing.T) {
+	var diag bytes.Buffer
+	l := New(&bytes.Buffer{}, "", 0)
+	l.SetInternalOutput(&diag)
+	fail := true
+	calls := 0
+	l.AddHookE(LevelError, func(e Entry) error {
+		calls++
+		if fail {
+			return errors.New("webhook down")
+		}
+		return nil
+	}, WithMaxFailures(2))
+
+	l.Error("1")
+	fail = false
+	l.Error("2") // resets the run of failures
+	fail = true
+	for i := 0; i < 4; i++ {
+		l.Error("x")
+	}
+
+	if calls != 4 {
+		t.Errorf("hook called %d times, want 4", calls)
+	}
+	stats := l.HookStats()
+	if len(stats) != 1 || stats[0].Failures != 3 || !stats[0].Disabled {
+		t.Errorf("HookStats = %+v", stats)
+	}
+	for _, s := range []string{"hook failed: webhook down", "hook disabled after 2 consecutive failures"} {
+		if !strings.Contains(diag.String(), s) {
+			t.Errorf("internal output does not contain %q:\n%s", s, diag.String())
+		}
+	}
+
+	// Panics count as failures too, and the hook is kept without a maximum.
+	diag.Reset()
+	l = New(&bytes.Buffer{}, "", 0)
+	l.SetInternalOutput(&diag)
+	l.AddHook(LevelError, func(e Entry) { panic("bad hook") })
+	for i := 0; i < 3; i++ {
+		l.Error("x")
+	}
+	if stats := l.HookStats(); len(stats) != 1 || stats[0].Failures != 3 || stats[0].Disabled {
+		t.Errorf("HookStats = %+v", stats)
+	}
+	if s := "recovered from panic in hook: string: bad hook"; !strings.Contains(diag.String(), s) {
+		t.Errorf("internal output does not contain %q:\n%s", s, diag.String())
+	}
+}
+
+func TestHookLevels(t *testing.T) {
+	l := New(&bytes.Buffer{}, "", 0)
+	var metrics, pager, filtered []string
+	l.AddHook(LevelDebug, func(e Entry) { metrics = append(metrics, e.Message) })
+	l.AddHook(LevelDebug, func(e Entry) { pager = append(pager, e.Message) }, WithHookLevels(LevelFatal, LevelPanic))
+	l.AddHook(LevelInfo, func(e Entry) { filtered = append(filtered, e.Message) }, WithHookFilter(func(e *Entry) bool {
+		return strings.HasPrefix(e.Message, "db")
+	}))
+	l.SetLevel(LevelDebug)
+
+	l.Debug("db debug")
+	l.Info("db info")
+	l.Warn("cache warn")
+	l.Log(LevelFatal, "db fatal")
+	l.Log(LevelPanic, "cache panic")
+
+	for _, c := range []struct {
+		name      string
+		got, want []string
+	}{
+		{"all", metrics, []string{"db debug", "db info", "cache warn", "db fatal", "cache panic"}},
+		{"fatal and panic", pager, []string{"db fatal", "cache panic"}},
+		{"filtered", filtered, []string{"db info", "db fatal"}},
+	} {
+		if strings.Join(c.got, ",") != strings.Join(c.want, ",") {
+			t.Errorf("%s hook called with %q, want %q", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestHookFilterPanic(t *testing.T) {
+	var diag bytes.Buffer
+	l := New(&bytes.Buffer{}, "", 0)
+	l.SetInternalOutput(&diag)
+	called := false
+	l.AddHook(LevelError, func(e Entry) { called = true }, WithHookFilter(func(e *Entry) bool { panic("bad filter") }))
+	l.Error("x")
+	if called {
+		t.Error("hook called after its filter panicked")
+	}
+	if s := "recovered from panic in hook filter"; !strings.Contains(diag.String(), s) {
+		t.Errorf("internal output does not contain %q:\n%s", s, diag.String())
+	}
+}
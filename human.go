@@ -0,0 +1,99 @@
+package log
+
+import (
+	"strconv"
+	"time"
+)
+
+// A humanizer is a field value with a human readable form and a raw form for
+// machine consumption.
+type humanizer interface {
+	human() string
+	raw() interface{}
+}
+
+// byteSize is the value of a Bytes field.
+type byteSize int64
+
+// Bytes returns a field for a number of bytes. It is rendered like 10.0MiB if
+// the logger is human readable, and as the plain number of bytes otherwise.
+func Bytes(key string, n int64) Field {
+	return Field{key, byteSize(n)}
+}
+
+func (n byteSize) raw() interface{} { return int64(n) }
+
+func (n byteSize) human() string {
+	const units = "KMGTPE"
+	// The magnitude as unsigned, which also holds that of math.MinInt64.
+	v := uint64(n)
+	if n < 0 {
+		v = -v
+	}
+	var s string
+	if v < 1024 {
+		s = strconv.FormatUint(v, 10) + "B"
+	} else {
+		f, i := float64(v)/1024, 0
+		// Values that would be rounded up to 1024.0 take the next unit.
+		for f >= 1024-0.05 && i < len(units)-1 {
+			f /= 1024
+			i++
+		}
+		s = strconv.FormatFloat(f, 'f', 1, 64) + units[i:i+1] + "iB"
+	}
+	if n < 0 {
+		return "-" + s
+	}
+	return s
+}
+
+// duration is the value of a Dur field.
+type duration time.Duration
+
+// Dur returns a field for a duration. It is rendered like 1m33.2s if the logger
+// is human readable, and as a number of milliseconds otherwise.
+func Dur(key string, d time.Duration) Field {
+	return Field{key, duration(d)}
+}
+
+func (d duration) raw() interface{} {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func (d duration) human() string {
+	v := time.Duration(d)
+	switch abs := v.Abs(); {
+	case abs >= time.Second:
+		v = v.Round(100 * time.Millisecond)
+	case abs >= time.Millisecond:
+		v = v.Round(100 * time.Microsecond)
+	}
+	return v.String()
+}
+
+// HumanReadable reports whether the logger renders field values in a human
+// readable form.
+func (l *Logger) HumanReadable() (v bool) {
+	l.mu.Lock()
+	v = l.human
+	l.mu.Unlock()
+	return
+}
+
+// SetHumanReadable sets whether the logger renders field values that have a
+// human readable form, such as those of Bytes and Dur fields, in that form
+// rather than as plain numbers. JSON output always has the plain numbers.
+func (l *Logger) SetHumanReadable(human bool) {
+	l.mu.Lock()
+	l.human = human
+	l.mu.Unlock()
+}
+
+func HumanReadable() bool {
+	return std.HumanReadable()
+}
+
+func SetHumanReadable(human bool) {
+	std.SetHumanReadable(human)
+}
@@ -0,0 +1,79 @@
+package log
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBytesHuman(t *testing.T) {
+	for _, c := range []struct {
+		n    int64
+		want string
+	}{
+		{0, "0B"},
+		{1, "1B"},
+		{1023, "1023B"},
+		{1024, "1.0KiB"},
+		{1536, "1.5KiB"},
+		{1024*1024 - 52, "1023.9KiB"},
+		{1024*1024 - 1, "1.0MiB"},
+		{1024 * 1024, "1.0MiB"},
+		{10 << 20, "10.0MiB"},
+		{1 << 30, "1.0GiB"},
+		{1 << 40, "1.0TiB"},
+		{1 << 50, "1.0PiB"},
+		{1 << 60, "1.0EiB"},
+		{math.MaxInt64, "8.0EiB"},
+		{-1, "-1B"},
+		{-1024, "-1.0KiB"},
+		{math.MinInt64, "-8.0EiB"},
+	} {
+		if got := byteSize(c.n).human(); got != c.want {
+			t.Errorf("Bytes(%d) = %q, want %q", c.n, got, c.want)
+		}
+	}
+}
+
+func TestDurHuman(t *testing.T) {
+	for _, c := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{1, "1ns"},
+		{999 * time.Microsecond, "999µs"},
+		{time.Millisecond, "1ms"},
+		{1234567 * time.Nanosecond, "1.2ms"},
+		{999960 * time.Microsecond, "1s"},
+		{time.Second, "1s"},
+		{93284 * time.Millisecond, "1m33.3s"},
+		{time.Hour, "1h0m0s"},
+		{-1500 * time.Millisecond, "-1.5s"},
+		{-time.Nanosecond, "-1ns"},
+		{math.MaxInt64, "2562047h47m16.854775807s"}, // too large to round
+		{math.MinInt64, "-2562047h47m16.854775808s"},
+	} {
+		if got := duration(c.d).human(); got != c.want {
+			t.Errorf("Dur(%v) = %q, want %q", c.d, got, c.want)
+		}
+	}
+}
+
+func TestHumanReadable(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	f := []interface{}{Bytes("size", 10<<20), Dur("elapsed", -93284*time.Millisecond)}
+	l.Errorw("copied", f...)
+	l.SetHumanReadable(true)
+	l.Errorw("copied", f...)
+	l.SetFlags(LJSON)
+	l.Errorw("copied", f...)
+	want := "copied size=10485760 elapsed=-93284\n" +
+		"copied size=10.0MiB elapsed=-1m33.3s\n" +
+		`{"level":"error","msg":"copied","size":10485760,"elapsed":-93284}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
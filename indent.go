@@ -0,0 +1,94 @@
+package log
+
+import "strings"
+
+// defaultIndent is the default string used for one level of indentation.
+const defaultIndent = "  "
+
+// Indent increases the indentation of the messages of subsequent entries by
+// one level. Every line of a multi-line message is indented, unless the
+// Lsingleline or Lquote flag puts the message on a single line.
+//
+// The indentation depth belongs to l itself. Loggers derived from l, like
+// those returned by With and Named, start at the depth l has when they are
+// created, and are indented independently afterwards. Concurrent workers that
+// indent their entries should each use a derived logger of their own.
+func (l *Logger) Indent() {
+	l.mu.Lock()
+	l.depth.Add(1)
+	l.mu.Unlock()
+}
+
+// Outdent decreases the indentation of the messages of subsequent entries by
+// one level. The depth does not go below zero.
+func (l *Logger) Outdent() {
+	l.mu.Lock()
+	l.outdent()
+	l.mu.Unlock()
+}
+
+// outdent decreases the indentation depth, if it is above zero. It must be
+// called with l.mu held.
+func (l *Logger) outdent() {
+	if d := l.depth.Load(); d > 0 {
+		l.depth.Store(d - 1)
+	}
+}
+
+// Group logs name at info level and indents subsequent entries. The returned
+// function undoes the indentation:
+//
+//	defer logger.Group("Building")()
+func (l *Logger) Group(name string) func() {
+	locked := l.lock()
+	if l.enabled(LevelInfo) {
+		l.format(LevelInfo, name)
+	}
+	l.depth.Add(1)
+	l.unlock(locked)
+	return l.Outdent
+}
+
+// SetIndentString sets the string used for one level of indentation. The
+// default is two spaces.
+func (l *Logger) SetIndentString(s string) {
+	l.mu.Lock()
+	l.indent = s
+	l.mu.Unlock()
+}
+
+func Indent() {
+	std.Indent()
+}
+
+func Outdent() {
+	std.Outdent()
+}
+
+func Group(name string) func() {
+	locked := std.lock()
+	if std.enabled(LevelInfo) {
+		std.format(LevelInfo, name)
+	}
+	std.depth.Add(1)
+	std.unlock(locked)
+	return std.Outdent
+}
+
+func SetIndentString(s string) {
+	std.SetIndentString(s)
+}
+
+// indentLines indents every line of s by depth levels of indent.
+func indentLines(s, indent string, depth int) string {
+	if indent == "" {
+		indent = defaultIndent
+	}
+	prefix := strings.Repeat(indent, depth)
+	body := strings.TrimSuffix(s, "\n")
+	body = prefix + strings.ReplaceAll(body, "\n", "\n"+prefix)
+	if strings.HasSuffix(s, "\n") {
+		body += "\n"
+	}
+	return body
+}
@@ -0,0 +1,74 @@
+package log
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestIndent(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	l.SetLevel(LevelInfo)
+
+	done := l.Group("Section")
+	l.Info("step\nwith two lines")
+	l.Indent()
+	l.SetIndentString("--")
+	l.Info("nested")
+	l.Outdent()
+	done()
+	l.Outdent() // does not go below zero
+	l.Info("end")
+	want := "Section\n" +
+		"  step\n  with two lines\n" +
+		"----nested\n" +
+		"end\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestIndentDerived(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	l.SetLevel(LevelInfo)
+	l.Indent()
+	w := l.With("k", 1)
+	n := l.Named("db")
+
+	// Derived loggers start at the depth of l, and are indented on their own.
+	w.Indent()
+	n.Outdent()
+	l.Info("parent")
+	w.Info("with")
+	n.Info("named")
+	want := "  parent\n" +
+		"    with k=1\n" +
+		"db: named\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestIndentConcurrent(t *testing.T) {
+	l := New(&syncBuffer{}, "", 0)
+	l.SetLevel(LevelInfo)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := l.With("worker", 1)
+			for j := 0; j < 100; j++ {
+				done := w.Group("task")
+				w.Info("working")
+				done()
+			}
+			if d := w.depth.Load(); d != 0 {
+				t.Errorf("worker ends at depth %d", d)
+			}
+		}()
+	}
+	wg.Wait()
+}
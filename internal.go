@@ -0,0 +1,127 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// internalLimit is the maximum number of internal messages written per second.
+const internalLimit = 10
+
+// internalQueue is the maximum number of internal messages waiting to be
+// written, see internalf.
+const internalQueue = 100
+
+// diag writes the internal messages of a logger, such as write errors and
+// recovered panics. It never passes them through the logging pipeline.
+type diag struct {
+	mu      sync.Mutex
+	w       io.Writer // nil means os.Stderr
+	window  time.Time
+	n       int
+	dropped int
+
+	qmu   sync.Mutex
+	queue []string // messages waiting for mu
+}
+
+// SetInternalOutput sets the destination for the internal messages of l, which
+// report problems in the logger itself: failed writes, entries that could not
+// be passed to the error reporter, and panics recovered while formatting an
+// entry. The default destination is os.Stderr.
+//
+// Internal messages are written to w directly, at most 10 per second; excess
+// messages are dropped and counted, see LevelStats.DroppedInternal. Since they
+// never pass through a Logger, w may be any writer, but a Logger that writes to
+// l itself must not be used.
+func (l *Logger) SetInternalOutput(w io.Writer) {
+	l.diag.mu.Lock()
+	defer l.diag.mu.Unlock()
+	l.diag.w = w
+}
+
+func SetInternalOutput(w io.Writer) {
+	std.SetInternalOutput(w)
+}
+
+// internalf writes an internal message. It may be called with or without l.mu
+// held. The message is queued, and written by whichever goroutine holds
+// l.diag.mu, so that it is not lost while another message is being written,
+// even if the internal output causes another internal message.
+func (l *Logger) internalf(format string, v ...interface{}) {
+	d := &l.diag
+	if !d.enqueue(fmt.Sprintf(format, v...)) {
+		l.stats.idrop.Add(1)
+	}
+	for d.pending() && d.mu.TryLock() {
+		for msg, ok := d.next(); ok; msg, ok = d.next() {
+			if !d.limit() {
+				l.stats.idrop.Add(1)
+				continue
+			}
+			d.write(msg)
+		}
+		d.mu.Unlock()
+	}
+}
+
+// enqueue queues msg, and reports whether there was room for it.
+func (d *diag) enqueue(msg string) bool {
+	d.qmu.Lock()
+	defer d.qmu.Unlock()
+	if len(d.queue) >= internalQueue {
+		return false
+	}
+	d.queue = append(d.queue, msg)
+	return true
+}
+
+// pending reports whether messages are queued.
+func (d *diag) pending() bool {
+	d.qmu.Lock()
+	defer d.qmu.Unlock()
+	return len(d.queue) > 0
+}
+
+// next removes the first queued message and returns it.
+func (d *diag) next() (msg string, ok bool) {
+	d.qmu.Lock()
+	defer d.qmu.Unlock()
+	if len(d.queue) == 0 {
+		return "", false
+	}
+	msg = d.queue[0]
+	d.queue = d.queue[1:]
+	return msg, true
+}
+
+// limit reports whether another message may be written in the current second.
+// It must be called with d.mu held.
+func (d *diag) limit() bool {
+	now := time.Now()
+	if now.Sub(d.window) >= time.Second {
+		if d.dropped > 0 {
+			d.write(fmt.Sprintf("%d internal messages dropped", d.dropped))
+		}
+		d.window = now
+		d.n = 0
+		d.dropped = 0
+	}
+	if d.n >= internalLimit {
+		d.dropped++
+		return false
+	}
+	d.n++
+	return true
+}
+
+func (d *diag) write(msg string) {
+	w := d.w
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, "log: %s\n", msg)
+}
@@ -0,0 +1,47 @@
+package log
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestInternalLimit(t *testing.T) {
+	var in syncBuffer
+	l := New(failingWriter{}, "", 0)
+	l.SetInternalOutput(&in)
+
+	for i := 0; i < 50; i++ {
+		l.Error("x")
+	}
+	if n := strings.Count(in.String(), "\n"); n != internalLimit {
+		t.Errorf("%d internal messages written, want %d:\n%s", n, internalLimit, in.String())
+	}
+	if got, want := l.Stats().DroppedInternal, uint64(50-internalLimit); got != want {
+		t.Errorf("DroppedInternal = %d, want %d", got, want)
+	}
+}
+
+func TestInternalConcurrent(t *testing.T) {
+	var in syncBuffer
+	l := New(failingWriter{}, "", 0)
+	l.SetInternalOutput(&in)
+
+	// Messages from concurrent goroutines are written or counted, never lost
+	// without a trace.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				l.internalf("message")
+			}
+		}()
+	}
+	wg.Wait()
+	written := uint64(strings.Count(in.String(), "log: message\n"))
+	if dropped := l.Stats().DroppedInternal; written+dropped != 40 {
+		t.Errorf("%d internal messages written and %d dropped, want 40 in total", written, dropped)
+	}
+}
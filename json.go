@@ -0,0 +1,272 @@
+package log
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// appendJSON appends an entry formatted for LJSON to b: a JSON object on a
+// single line. It must be called directly by format or sprintEntry, or with
+// l.skip set accordingly.
+//
+// Unless a JSONFormatter is set, see SetJSONFormatter, the object has the keys
+// time (if Ldate, Ltime or Lmicroseconds is set, in RFC 3339 format), level,
+// level_num (see SetLevelNumbering), prefix (if not empty), logger (for
+// loggers returned by Named), file and line (if Llongfile or Lshortfile is
+// set), code (for entries logged by Errorc and the like) and msg, followed by
+// the fields of the entry. Field values that are booleans, numbers or strings
+// keep their JSON type; times are written in RFC 3339 format, byte slices in
+// base64 and durations as for text output. A group of fields becomes a nested
+// object, and other values are rendered as for text output.
+func (l *Logger) appendJSON(b []byte, level int, msg string, fields []Field, extra entryExtra) []byte {
+	if l.jfmt != nil {
+		b = l.jfmt.appendEntry(b, l, l.jsonEntry(level, msg, fields, extra))
+	} else {
+		b = l.appendJSONObject(b, level, msg, fields, extra)
+	}
+	if l.eol != "" {
+		return append(b, l.eol...)
+	}
+	return append(b, '\n')
+}
+
+// appendJSONObject appends the JSON object of an entry in the default layout
+// to b. It must be called directly by appendJSON.
+func (l *Logger) appendJSONObject(b []byte, level int, msg string, fields []Field, extra entryExtra) []byte {
+	b = append(b, '{')
+	if l.flag&(Ldate|Ltime|Lmicroseconds) != 0 {
+		t := l.now()
+		if l.flag&LUTC != 0 {
+			t = t.UTC()
+		}
+		layout := time.RFC3339
+		if l.flag&Lmicroseconds != 0 {
+			layout = "2006-01-02T15:04:05.000000Z07:00"
+		}
+		b = appendJSONKey(b, "time")
+		b = appendJSONTime(b, t, layout)
+	}
+	b = appendJSONKey(b, "level")
+	b = appendJSONString(b, strings.ToLower(levelName(level)))
+	if l.lnum != NumberingNone {
+		b = appendJSONKey(b, "level_num")
+		b = appendJSONValue(b, levelNumber(l.lnum, level))
+	}
+	if prefix := l.Prefix(); prefix != "" {
+		b = appendJSONKey(b, "prefix")
+		b = appendJSONString(b, prefix)
+	}
+	if l.comp != nil {
+		b = appendJSONKey(b, "logger")
+		b = appendJSONString(b, l.comp.name)
+	}
+	if l.flag&(Lshortfile|Llongfile) != 0 {
+		// Skip appendJSONObject, appendJSON, format and the logging method.
+		_, file, line, ok := runtime.Caller(4 + l.skip)
+		if !ok {
+			file, line = "???", 0
+		}
+		if l.flag&Lshortfile != 0 {
+			file = filepath.Base(file)
+		}
+		b = appendJSONKey(b, "file")
+		b = appendJSONString(b, file)
+		b = appendJSONKey(b, "line")
+		b = appendJSONValue(b, line)
+	}
+	if extra.code != "" {
+		b = appendJSONKey(b, "code")
+		b = appendJSONString(b, extra.code)
+	}
+	b = appendJSONKey(b, "msg")
+	b = appendJSONString(b, strings.TrimSuffix(msg, "\n"))
+	for _, f := range fields {
+		b = appendJSONKey(b, f.Key)
+		b = l.appendJSONField(b, f.Value)
+	}
+	return append(b, '}')
+}
+
+func appendJSONKey(b []byte, key string) []byte {
+	if b[len(b)-1] != '{' {
+		b = append(b, ',')
+	}
+	b = appendJSONString(b, key)
+	return append(b, ':')
+}
+
+func (l *Logger) appendJSONField(b []byte, value interface{}) []byte {
+	value = l.resolve(value)
+	if g, ok := value.(group); ok {
+		b = append(b, '{')
+		for _, f := range g {
+			b = appendJSONKey(b, f.Key)
+			b = l.appendJSONField(b, f.Value)
+		}
+		return append(b, '}')
+	}
+	// JSON is for machines, so values are written raw even if l is human
+	// readable.
+	if h, ok := value.(humanizer); ok {
+		value = h.raw()
+	}
+	switch v := value.(type) {
+	case nil, bool, string,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return appendJSONValue(b, value)
+	case time.Time:
+		return appendJSONTime(b, v, time.RFC3339Nano)
+	case time.Duration:
+		return appendJSONString(b, v.String())
+	case []byte:
+		b = append(b, '"')
+		n := len(b)
+		b = append(b, make([]byte, base64.StdEncoding.EncodedLen(len(v)))...)
+		base64.StdEncoding.Encode(b[n:], v)
+		return append(b, '"')
+	}
+	return appendJSONString(b, l.fieldString(value))
+}
+
+// appendJSONTime appends t, formatted with layout, as a JSON string to b.
+func appendJSONTime(b []byte, t time.Time, layout string) []byte {
+	b = append(b, '"')
+	b = t.AppendFormat(b, layout)
+	return append(b, '"')
+}
+
+// appendJSONValue appends the JSON encoding of v, which must be a nil, boolean,
+// number or string, to b. HTML characters are not escaped, and numbers that
+// JSON cannot represent, like NaN, are written as strings. The output is the
+// same as that of encoding/json, without its use of reflection, which is only
+// left to other values.
+func appendJSONValue(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, "null"...)
+	case string:
+		return appendJSONString(b, v)
+	case bool:
+		return strconv.AppendBool(b, v)
+	case int:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int8:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int16:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int32:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(b, v, 10)
+	case uint:
+		return strconv.AppendUint(b, uint64(v), 10)
+	case uint8:
+		return strconv.AppendUint(b, uint64(v), 10)
+	case uint16:
+		return strconv.AppendUint(b, uint64(v), 10)
+	case uint32:
+		return strconv.AppendUint(b, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(b, v, 10)
+	case float32:
+		return appendJSONFloat(b, float64(v), 32)
+	case float64:
+		return appendJSONFloat(b, v, 64)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return appendJSONString(b, fmt.Sprint(v))
+	}
+	return append(b, bytes.TrimSuffix(buf.Bytes(), []byte("\n"))...)
+}
+
+// appendJSONFloat appends f, of the given bit size, to b like encoding/json:
+// like %g, but with the exponent cutoffs of JavaScript.
+func appendJSONFloat(b []byte, f float64, bits int) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return appendJSONString(b, strconv.FormatFloat(f, 'g', -1, bits))
+	}
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b = strconv.AppendFloat(b, f, format, -1, bits)
+	if format == 'e' {
+		// Write e-09 as e-9.
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s as a JSON string to b, escaped like encoding/json
+// without HTML escaping: invalid UTF-8 is replaced by U+FFFD, and U+2028 and
+// U+2029 are escaped.
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= ' ' && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, string(utf8.RuneError)...)
+		} else if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hexDigits[r&0xf])
+		} else {
+			i += size
+			continue
+		}
+		i += size
+		start = i
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
@@ -0,0 +1,272 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A JSONFormatter lays out the JSON object written for each entry with LJSON,
+// for log collectors that expect particular keys, see SetJSONFormatter.
+type JSONFormatter interface {
+	appendEntry(b []byte, l *Logger, e *jsonEntry) []byte
+}
+
+// jsonEntry is an entry as laid out by a JSONFormatter.
+type jsonEntry struct {
+	time   time.Time
+	level  int
+	msg    string
+	file   string // "" if neither Lshortfile nor Llongfile is set
+	line   int
+	fn     string
+	fields []Field
+	extra  entryExtra
+}
+
+// JSONFormatter returns the formatter of the JSON output of l, or nil for the
+// default layout.
+func (l *Logger) JSONFormatter() JSONFormatter {
+	defer l.unlock(l.lock())
+	return l.jfmt
+}
+
+// SetJSONFormatter sets the formatter that lays out the JSON object written
+// for each entry with LJSON, like GCPFormatter. With a formatter, the time of
+// the entry is always written, and the caller if Lshortfile or Llongfile is
+// set; the other flags are ignored, as they are for the default layout. A nil
+// f restores the default layout, described for LJSON.
+func (l *Logger) SetJSONFormatter(f JSONFormatter) {
+	defer l.unlock(l.lock())
+	l.jfmt = f
+}
+
+func SetJSONFormatter(f JSONFormatter) {
+	std.SetJSONFormatter(f)
+}
+
+// jsonEntry returns the entry to be laid out by the JSONFormatter of l. It
+// must be called directly by appendJSON.
+func (l *Logger) jsonEntry(level int, msg string, fields []Field, extra entryExtra) *jsonEntry {
+	e := &jsonEntry{
+		time:   l.now(),
+		level:  level,
+		msg:    strings.TrimSuffix(msg, "\n"),
+		fields: fields,
+		extra:  extra,
+	}
+	if l.flag&LUTC != 0 {
+		e.time = e.time.UTC()
+	}
+	if l.flag&(Lshortfile|Llongfile) != 0 {
+		// Skip jsonEntry, appendJSON, format and the logging method.
+		pc, file, line, ok := runtime.Caller(4 + l.skip)
+		if !ok {
+			file, line = "???", 0
+		}
+		if l.flag&Lshortfile != 0 {
+			file = filepath.Base(file)
+		}
+		e.file, e.line = file, line
+		if f := runtime.FuncForPC(pc); f != nil {
+			e.fn = f.Name()
+		}
+	}
+	return e
+}
+
+// GCPFormatter lays out entries for Google Cloud Logging, which reads the
+// following keys from the JSON objects written to standard output on GKE and
+// Cloud Run:
+//
+//	time                                   the time of the entry, in RFC 3339 format
+//	severity                               DEBUG, INFO, WARNING, ERROR or CRITICAL
+//	message                                the message
+//	logging.googleapis.com/sourceLocation  the file, line and function of the caller
+//	logging.googleapis.com/trace           the trace ID, as projects/ProjectID/traces/ID
+//	logging.googleapis.com/spanId          the span ID
+//
+// LevelFatal and LevelPanic map to CRITICAL, and LevelDebug and the custom
+// levels below it to DEBUG. The name of a logger returned by Named and the code
+// of entries logged by Errorc and the like are written under logger and code,
+// followed by the fields of the entry, which Cloud Logging keeps in the
+// jsonPayload of the entry.
+type GCPFormatter struct {
+	// ProjectID is the Google Cloud project of the traces. If it is empty,
+	// the trace ID is written as is.
+	ProjectID string
+
+	// Trace returns the trace and span ID carried by the context of an entry
+	// logged by a *Ctx method, like InfoCtx, or empty strings. If it is nil,
+	// or for entries logged without a context, the trace and span ID are
+	// taken from the fields of the entry with the keys trace_id and span_id,
+	// such as those carried by a context, see NewContext.
+	Trace func(ctx context.Context) (traceID, spanID string)
+}
+
+func (f GCPFormatter) appendEntry(b []byte, l *Logger, e *jsonEntry) []byte {
+	b = append(b, '{')
+	b = appendJSONKey(b, "time")
+	b = appendJSONValue(b, e.time.Format(time.RFC3339Nano))
+	b = appendJSONKey(b, "severity")
+	b = appendJSONValue(b, gcpSeverity(e.level))
+	b = appendJSONKey(b, "message")
+	b = appendJSONValue(b, e.msg)
+	if e.file != "" {
+		b = appendJSONKey(b, "logging.googleapis.com/sourceLocation")
+		b = append(b, '{')
+		b = appendJSONKey(b, "file")
+		b = appendJSONValue(b, e.file)
+		b = appendJSONKey(b, "line")
+		b = appendJSONValue(b, strconv.Itoa(e.line))
+		if e.fn != "" {
+			b = appendJSONKey(b, "function")
+			b = appendJSONValue(b, e.fn)
+		}
+		b = append(b, '}')
+	}
+	if trace, span := f.trace(e); trace != "" {
+		if f.ProjectID != "" {
+			trace = "projects/" + f.ProjectID + "/traces/" + trace
+		}
+		b = appendJSONKey(b, "logging.googleapis.com/trace")
+		b = appendJSONValue(b, trace)
+		if span != "" {
+			b = appendJSONKey(b, "logging.googleapis.com/spanId")
+			b = appendJSONValue(b, span)
+		}
+	}
+	if name := l.name(); name != "" {
+		b = appendJSONKey(b, "logger")
+		b = appendJSONValue(b, name)
+	}
+	if e.extra.code != "" {
+		b = appendJSONKey(b, "code")
+		b = appendJSONValue(b, e.extra.code)
+	}
+	for _, field := range e.fields {
+		b = appendJSONKey(b, field.Key)
+		b = l.appendJSONField(b, field.Value)
+	}
+	return append(b, '}')
+}
+
+// trace returns the trace and span ID of e.
+func (f GCPFormatter) trace(e *jsonEntry) (traceID, spanID string) {
+	if f.Trace != nil && e.extra.ctx != nil {
+		if traceID, spanID = f.Trace(e.extra.ctx); traceID != "" {
+			return traceID, spanID
+		}
+	}
+	for _, field := range e.fields {
+		switch s, _ := field.Value.(string); field.Key {
+		case "trace_id":
+			traceID = s
+		case "span_id":
+			spanID = s
+		}
+	}
+	return traceID, spanID
+}
+
+// gcpSeverity returns the Cloud Logging severity of level.
+func gcpSeverity(level int) string {
+	switch {
+	case level <= LevelPanic:
+		return "CRITICAL"
+	case level == LevelError:
+		return "ERROR"
+	case level == LevelWarn:
+		return "WARNING"
+	case level == LevelInfo:
+		return "INFO"
+	}
+	return "DEBUG"
+}
+
+// ecsVersion is the version of the Elastic Common Schema written by
+// ECSFormatter.
+const ecsVersion = "8.11.0"
+
+// ECSFormatter lays out entries according to the Elastic Common Schema, with
+// the keys
+//
+//	@timestamp            the time of the entry, in UTC with milliseconds
+//	log.level             the level name, like warn
+//	message               the message
+//	ecs.version           the version of the schema, 8.11.0
+//	log.logger            the name of a logger returned by Named
+//	log.origin.file.name  the file of the caller
+//	log.origin.file.line  the line of the caller
+//	log.origin.function   the function of the caller
+//	error.message         the error logged by Wrap and the like, or the first field holding an error
+//	error.type            the Go type of the error
+//	error.code            the code of entries logged by Errorc and the like
+//	error.stack_trace     the stack of a panic recovered by Recover
+//	labels                an object holding the other fields of the entry
+type ECSFormatter struct{}
+
+func (ECSFormatter) appendEntry(b []byte, l *Logger, e *jsonEntry) []byte {
+	b = append(b, '{')
+	b = appendJSONKey(b, "@timestamp")
+	b = appendJSONValue(b, e.time.UTC().Format("2006-01-02T15:04:05.000Z07:00"))
+	b = appendJSONKey(b, "log.level")
+	b = appendJSONValue(b, strings.ToLower(levelName(e.level)))
+	b = appendJSONKey(b, "message")
+	b = appendJSONValue(b, e.msg)
+	b = appendJSONKey(b, "ecs.version")
+	b = appendJSONValue(b, ecsVersion)
+	if name := l.name(); name != "" {
+		b = appendJSONKey(b, "log.logger")
+		b = appendJSONValue(b, name)
+	}
+	if e.file != "" {
+		b = appendJSONKey(b, "log.origin.file.name")
+		b = appendJSONValue(b, e.file)
+		b = appendJSONKey(b, "log.origin.file.line")
+		b = appendJSONValue(b, e.line)
+		if e.fn != "" {
+			b = appendJSONKey(b, "log.origin.function")
+			b = appendJSONValue(b, e.fn)
+		}
+	}
+	err, errField := e.extra.err, -1
+	if err == nil {
+		for i, field := range e.fields {
+			if fe, ok := field.Value.(error); ok && fe != nil {
+				err, errField = fe, i
+				break
+			}
+		}
+	}
+	if err != nil {
+		b = appendJSONKey(b, "error.message")
+		b = appendJSONValue(b, err.Error())
+		b = appendJSONKey(b, "error.type")
+		b = appendJSONValue(b, fmt.Sprintf("%T", err))
+	}
+	if e.extra.code != "" {
+		b = appendJSONKey(b, "error.code")
+		b = appendJSONValue(b, e.extra.code)
+	}
+	if len(e.extra.stack) > 0 {
+		b = appendJSONKey(b, "error.stack_trace")
+		b = appendJSONValue(b, string(e.extra.stack))
+	}
+	if len(e.fields) > 0 && !(len(e.fields) == 1 && errField == 0) {
+		b = appendJSONKey(b, "labels")
+		b = append(b, '{')
+		for i, field := range e.fields {
+			if i != errField {
+				b = appendJSONKey(b, field.Key)
+				b = l.appendJSONField(b, field.Value)
+			}
+		}
+		b = append(b, '}')
+	}
+	return append(b, '}')
+}
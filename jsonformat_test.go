@@ -0,0 +1,164 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+type traceKey struct{}
+
+func TestGCPFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", LJSON|Lshortfile)
+	l.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 5000, time.UTC) }
+	l.SetLevel(LevelDebug)
+	l.SetJSONFormatter(GCPFormatter{
+		ProjectID: "acme",
+		Trace: func(ctx context.Context) (string, string) {
+			id, _ := ctx.Value(traceKey{}).(string)
+			return id, "s1"
+		},
+	})
+	ctx := context.WithValue(context.Background(), traceKey{}, "t1")
+
+	_, _, line, _ := runtime.Caller(0)
+	l.WarnCtx(ctx, "slow")
+	l.Named("db").Errorw("query failed", "rows", 3)
+	l.With("trace_id", "t2").Log(LevelFatal, "line\nbreak")
+
+	want := fmt.Sprintf(`{"time":"2024-05-01T12:00:00.000005Z","severity":"WARNING","message":"slow",`+
+		`"logging.googleapis.com/sourceLocation":{"file":"jsonformat_test.go","line":"%d","function":"github.com/semrekkers/log.TestGCPFormatter"},`+
+		`"logging.googleapis.com/trace":"projects/acme/traces/t1","logging.googleapis.com/spanId":"s1"}`+"\n", line+1) +
+		fmt.Sprintf(`{"time":"2024-05-01T12:00:00.000005Z","severity":"ERROR","message":"query failed",`+
+			`"logging.googleapis.com/sourceLocation":{"file":"jsonformat_test.go","line":"%d","function":"github.com/semrekkers/log.TestGCPFormatter"},`+
+			`"logger":"db","rows":3}`+"\n", line+2)
+	got := buf.String()
+	if len(got) < len(want) || got[:len(want)] != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+
+	// Each entry is a single line, and the trace is taken from the fields
+	// without a context.
+	var e map[string]interface{}
+	if err := json.Unmarshal([]byte(got[len(want):]), &e); err != nil {
+		t.Fatalf("last entry %q: %v", got[len(want):], err)
+	}
+	if e["severity"] != "CRITICAL" || e["message"] != "line\nbreak" || e["logging.googleapis.com/trace"] != "projects/acme/traces/t2" {
+		t.Errorf("last entry = %v", e)
+	}
+}
+
+func TestECSFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", LJSON|Lshortfile)
+	l.now = func() time.Time { return time.Date(2024, 5, 1, 14, 0, 0, 0, time.FixedZone("CEST", 2*3600)) }
+	l.SetJSONFormatter(ECSFormatter{})
+
+	_, _, line, _ := runtime.Caller(0)
+	l.Named("store").With("tenant", "acme").Wrap(errors.New("disk full"), "saving")
+	l.Errorw("retrying", "err", &tenantError{"acme", errors.New("busy")}, "attempt", 2)
+
+	// Sample ECS documents, as accepted by Elasticsearch.
+	want := fmt.Sprintf(`{"@timestamp":"2024-05-01T12:00:00.000Z","log.level":"error","message":"saving: disk full","ecs.version":"8.11.0",`+
+		`"log.logger":"store","log.origin.file.name":"jsonformat_test.go","log.origin.file.line":%d,"log.origin.function":"github.com/semrekkers/log.TestECSFormatter",`+
+		`"error.message":"saving: disk full","error.type":"*fmt.wrapError","labels":{"tenant":"acme"}}`+"\n", line+1) +
+		fmt.Sprintf(`{"@timestamp":"2024-05-01T12:00:00.000Z","log.level":"error","message":"retrying","ecs.version":"8.11.0",`+
+			`"log.origin.file.name":"jsonformat_test.go","log.origin.file.line":%d,"log.origin.function":"github.com/semrekkers/log.TestECSFormatter",`+
+			`"error.message":"acme: busy","error.type":"*log.tenantError","labels":{"attempt":2}}`+"\n", line+2)
+	if got := buf.String(); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	// A recovered panic fills in the stack trace.
+	buf.Reset()
+	func() {
+		defer l.Recover("handler")
+		panic("boom")
+	}()
+	var e map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("entry %q: %v", buf.String(), err)
+	}
+	if stack, _ := e["error.stack_trace"].(string); !strings.Contains(stack, "TestECSFormatter") {
+		t.Errorf("error.stack_trace = %q", stack)
+	}
+}
+
+func TestAppendJSONValue(t *testing.T) {
+	var all []byte
+	for c := 0; c < 0x80; c++ {
+		all = append(all, byte(c))
+	}
+	values := []interface{}{
+		nil, true, false, "", "plain", string(all), "<a href=\"x\">&amp;</a>",
+		"héllo wörld ✓ 🙂", "bad \xff\xfe utf-8 \xe2\x82", "line para ",
+		0, -1, int8(-128), int16(12345), int32(-7), int64(1) << 62,
+		uint(7), uint8(255), uint16(65535), uint32(1) << 31, uint64(math.MaxUint64),
+		0.0, -0.0, 1.5, -3.25, 1e20, 1e21, 1e-6, 1e-7, 123456789.125, 5e-324,
+		float32(0.1), float32(1e21), float32(1e-7), float32(3.4e38),
+	}
+	for _, v := range values {
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s, ok := v.(string); ok {
+			// encoding/json escapes HTML characters by default.
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			enc.Encode(s)
+			want = bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+		}
+		if got := appendJSONValue(nil, v); !bytes.Equal(got, want) {
+			t.Errorf("appendJSONValue(%#v) = %s, want %s", v, got, want)
+		}
+	}
+	for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		if got, want := string(appendJSONValue(nil, v)), `"`+fmt.Sprint(v)+`"`; got != want {
+			t.Errorf("appendJSONValue(%v) = %s, want %s", v, got, want)
+		}
+	}
+}
+
+func TestJSONFieldTypes(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", LJSON)
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 5000, time.FixedZone("", 2*60*60))
+	l.Errorw("typed",
+		"time", ts,
+		"took", 1500*time.Millisecond,
+		"raw", []byte("hi\x00"),
+		"err", errors.New(`quote "me"`),
+		"ratio", 0.25,
+		"n", uint64(1)<<63,
+	)
+	want := `{"level":"error","msg":"typed","time":"2024-05-01T12:00:00.000005+02:00","took":"1.5s",` +
+		`"raw":"aGkA","err":"quote \"me\"","ratio":0.25,"n":9223372036854775808}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %s, want %s", got, want)
+	}
+	if !json.Valid(buf.Bytes()) {
+		t.Errorf("output is not valid JSON: %s", buf.String())
+	}
+}
+
+func BenchmarkJSONFields(b *testing.B) {
+	l := New(io.Discard, "", LstdFlags|LJSON)
+	l.SetLevel(LevelInfo)
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Infow("request", "method", "GET", "path", "/users/\"42\"", "status", 200,
+			"bytes", uint64(5120), "ratio", 0.75, "ok", true, "at", ts, "took", 12*time.Millisecond)
+	}
+}
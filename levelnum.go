@@ -0,0 +1,60 @@
+package log
+
+// A LevelNumbering is the scheme of the numeric level written with LJSON and
+// Llogfmt, see SetLevelNumbering.
+type LevelNumbering int
+
+const (
+	NumberingNone   LevelNumbering = iota // no numeric level
+	NumberingLevel                        // the level itself: 0 for LevelFatal up to 5 for LevelDebug
+	NumberingSyslog                       // the syslog severity: 2 (critical) for LevelFatal up to 7 (debug) for LevelDebug
+)
+
+// LevelNumbering returns the scheme of the numeric level in structured output.
+func (l *Logger) LevelNumbering() LevelNumbering {
+	defer l.unlock(l.lock())
+	return l.lnum
+}
+
+// SetLevelNumbering adds the numeric level of each entry, under the key
+// level_num, after its level name in the output of LJSON and Llogfmt, so that
+// pipelines can filter entries by comparing numbers, like level_num <= 3.
+//
+// With NumberingLevel, the number is the level as defined by this package, so
+// that lower numbers are more severe and levels registered with RegisterLevel
+// report the number they were registered with. With NumberingSyslog, it is the
+// syslog severity, as written by SyslogWriter: 2 for LevelFatal and
+// LevelPanic, 3 for LevelError, 4 for LevelWarn, 6 for LevelInfo and 7 for
+// LevelDebug and the custom levels below it. The default is NumberingNone,
+// which leaves the numeric level out.
+func (l *Logger) SetLevelNumbering(n LevelNumbering) {
+	defer l.unlock(l.lock())
+	l.lnum = n
+}
+
+func SetLevelNumbering(n LevelNumbering) {
+	std.SetLevelNumbering(n)
+}
+
+// levelNumber returns the numeric level of level in the scheme n.
+func levelNumber(n LevelNumbering, level int) int {
+	if n == NumberingSyslog {
+		return syslogSeverity(level)
+	}
+	return level
+}
+
+// syslogSeverity returns the syslog severity of level.
+func syslogSeverity(level int) int {
+	switch {
+	case level <= LevelPanic:
+		return 2
+	case level == LevelError:
+		return 3
+	case level == LevelWarn:
+		return 4
+	case level == LevelInfo:
+		return 6
+	}
+	return 7
+}
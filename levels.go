@@ -0,0 +1,180 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// MaxLevel is the highest log level that can be registered with RegisterLevel.
+const MaxLevel = 15
+
+// levelTable describes the registered log levels. It is never modified once
+// it is in use; RegisterLevel replaces it as a whole.
+type levelTable struct {
+	label [MaxLevel + 1]string
+	color [MaxLevel + 1]int
+	width int // length of the longest label
+	max   int // highest registered level
+}
+
+var (
+	// levels contains the current levelTable.
+	levels atomic.Pointer[levelTable]
+
+	// registerMu serializes calls to RegisterLevel.
+	registerMu sync.Mutex
+)
+
+func init() {
+	t := &levelTable{}
+	for level, l := range []struct {
+		label string
+		color int
+	}{
+		LevelFatal: {"FATAL", colorRed},
+		LevelPanic: {"PANIC", colorRed},
+		LevelError: {"ERROR", colorRed},
+		LevelWarn:  {"WARN", colorYellow},
+		LevelInfo:  {"INFO", colorBlue},
+		LevelDebug: {"DEBUG", colorGreen},
+	} {
+		t.set(level, l.label, l.color)
+	}
+	levels.Store(t)
+}
+
+func (t *levelTable) set(level int, label string, color int) {
+	t.label[level] = label
+	t.color[level] = color
+	t.width = 0
+	for _, l := range t.label {
+		if len(l) > t.width {
+			t.width = len(l)
+		}
+	}
+	if level > t.max {
+		t.max = level
+	}
+}
+
+// RegisterLevel registers a log level with the given label and ANSI color code,
+// such as 35 for magenta, or 0 for no color. The level can then be logged at
+// with Log and Logf, and selected with SetLevel and ParseLevel, which accepts
+// the label in lowercase. Registering a built-in level, like LevelWarn, changes
+// its label and color. For example:
+//
+//	const LevelTrace = log.LevelDebug + 1
+//
+//	func init() {
+//		log.RegisterLevel(LevelTrace, "TRACE", 35)
+//	}
+//
+// Labels are padded with spaces to the length of the longest one, so that the
+// messages after them line up. Levels are shared by all loggers; they are
+// usually registered during initialization, but RegisterLevel is safe to call
+// at any time. It panics if level is not between 0 and MaxLevel, or if label is
+// empty or contains spaces or brackets.
+func RegisterLevel(level int, label string, color int) {
+	if level < 0 || level > MaxLevel {
+		panic(fmt.Sprintf("log: level %d out of range", level))
+	}
+	if label == "" || strings.ContainsAny(label, " []") {
+		panic(fmt.Sprintf("log: invalid label %q", label))
+	}
+	registerMu.Lock()
+	defer registerMu.Unlock()
+	t := *levels.Load()
+	t.set(level, label, color)
+	levels.Store(&t)
+}
+
+// validLevel reports whether level is registered.
+func validLevel(level int) bool {
+	return level >= 0 && level <= MaxLevel && levels.Load().label[level] != ""
+}
+
+// levelLabel returns the label of level, padded to the length of the longest
+// label.
+func levelLabel(level int) string {
+	label := levelName(level)
+	if n := levels.Load().width - len(label); n > 0 {
+		label += strings.Repeat(" ", n)
+	}
+	return label
+}
+
+// levelName returns the label of level, or its number if it is not registered.
+func levelName(level int) string {
+	if !validLevel(level) {
+		return strconv.Itoa(level)
+	}
+	return levels.Load().label[level]
+}
+
+// levelColor returns the color of level.
+func levelColor(level int) int {
+	if !validLevel(level) {
+		return colorNone
+	}
+	return levels.Load().color[level]
+}
+
+// labelLevel returns the level of a label as written with Llabel, or -1.
+func labelLevel(label string) int {
+	label = strings.TrimRight(label, " ")
+	t := levels.Load()
+	for level, l := range t.label {
+		if l != "" && l == label {
+			return level
+		}
+	}
+	return -1
+}
+
+// Log logs v at the given level, which may be a level registered with
+// RegisterLevel. Arguments are handled in the manner of fmt.Print. Unlike Fatal
+// and Panic, Log does not exit or panic at those levels. It panics if level is
+// not registered.
+func (l *Logger) Log(level int, v ...interface{}) {
+	if !validLevel(level) {
+		panic("invalid log level")
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(level) {
+		l.format(level, l.sprint(v...))
+	}
+}
+
+// Logf is like Log, but arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Logf(level int, format string, v ...interface{}) {
+	if !validLevel(level) {
+		panic("invalid log level")
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(level) {
+		l.format(level, l.sprintf(format, v...))
+	}
+}
+
+func Log(level int, v ...interface{}) {
+	if !validLevel(level) {
+		panic("invalid log level")
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(level) {
+		std.format(level, std.sprint(v...))
+	}
+}
+
+func Logf(level int, format string, v ...interface{}) {
+	if !validLevel(level) {
+		panic("invalid log level")
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(level) {
+		std.format(level, std.sprintf(format, v...))
+	}
+}
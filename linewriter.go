@@ -0,0 +1,170 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+
+	golog "log"
+)
+
+// DefaultMaxLineLength is the maximum line length used by Copy when none is given.
+const DefaultMaxLineLength = 64 * 1024
+
+// continuation marks the ends of a line that was split by Copy.
+const continuation = "..."
+
+// lineWriter is the io.WriteCloser returned by NewLineWriter.
+type lineWriter struct {
+	mu       sync.Mutex
+	l        *Logger
+	level    int
+	prefix   string
+	buf      []byte
+	classify bool
+}
+
+// NewLineWriter returns a writer that logs each line written to it as a separate
+// entry at the given level, with prefix prepended to the message. Bytes are
+// buffered across calls to Write until a line is complete. Progress lines that
+// are only terminated by a carriage return overwrite each other, so that only
+// the last one is logged. Close logs the remaining partial line, if any.
+//
+// It is useful for capturing the output of a subprocess:
+//
+//	cmd := exec.Command("make")
+//	stdout := log.NewLineWriter(logger, log.LevelInfo, "make: ")
+//	stderr := log.NewLineWriter(logger, log.LevelWarn, "make: ")
+//	cmd.Stdout, cmd.Stderr = stdout, stderr
+//	err := cmd.Run()
+//	stdout.Close()
+//	stderr.Close()
+func NewLineWriter(l *Logger, level int, prefix string) io.WriteCloser {
+	return &lineWriter{
+		l:      l,
+		level:  level,
+		prefix: prefix,
+	}
+}
+
+// Writer returns a writer that logs each line written to it as a separate
+// entry at the given level, like one returned by NewLineWriter without prefix.
+// It is safe for concurrent use; a partial line is kept until it is completed
+// by a later write.
+func (l *Logger) Writer(level int) io.Writer {
+	return &lineWriter{l: l, level: level}
+}
+
+// StdLogger returns a standard library logger that logs each line written to
+// it as a separate entry at the given level, for libraries that accept only a
+// *log.Logger from the standard library:
+//
+//	srv := &http.Server{ErrorLog: logger.StdLogger(log.LevelError)}
+//
+// The returned logger has no flags or prefix, since l writes its own header.
+func (l *Logger) StdLogger(level int) *golog.Logger {
+	return golog.New(l.Writer(level), "", 0)
+}
+
+func (w *lineWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	start := 0
+	for {
+		i := bytes.IndexByte(w.buf[start:], '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(w.buf[start : start+i])
+		start += i + 1
+	}
+	rest := w.buf[start:]
+	// Drop progress lines that have been overwritten by a later one.
+	if i := bytes.LastIndexByte(rest, '\r'); i >= 0 && i < len(rest)-1 {
+		rest = rest[i+1:]
+	}
+	w.buf = w.buf[:copy(w.buf, rest)]
+	return len(p), nil
+}
+
+// Close logs the remaining partial line, if any.
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = w.buf[:0]
+	}
+	return nil
+}
+
+func (w *lineWriter) emit(line []byte) {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	if i := bytes.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+	level := w.level
+	if w.classify {
+		level = classifyLine(line, level)
+	}
+	w.l.log(level, w.prefix+string(line))
+}
+
+// classifyLine returns the level suggested by words in line like "error" or
+// "warning", if it is more severe than level, and level otherwise.
+func classifyLine(line []byte, level int) int {
+	lower := bytes.ToLower(line)
+	switch {
+	case level > LevelError && bytes.Contains(lower, []byte("error")):
+		return LevelError
+	case level > LevelWarn && bytes.Contains(lower, []byte("warn")):
+		return LevelWarn
+	}
+	return level
+}
+
+// Copy reads lines from r until EOF and logs each one as a separate entry at
+// the given level. It returns the number of bytes read and the first error
+// encountered other than io.EOF. Lines longer than maxLine bytes are split
+// into several entries, marked with "..." where they were split. If maxLine is
+// not positive, DefaultMaxLineLength is used.
+//
+// Copy blocks until r returns an error. To stop it early, close the reader, as
+// is possible with pipes, files and network connections.
+func Copy(l *Logger, level int, r io.Reader, maxLine int) (n int64, err error) {
+	if maxLine <= 0 {
+		maxLine = DefaultMaxLineLength
+	}
+	br := bufio.NewReaderSize(r, maxLine)
+	split := false
+	for {
+		line, err := br.ReadSlice('\n')
+		n += int64(len(line))
+		if err == bufio.ErrBufferFull {
+			s := string(line) + continuation
+			if split {
+				s = continuation + s
+			}
+			l.log(level, s)
+			split = true
+			continue
+		}
+		if len(line) > 0 {
+			s := strings.TrimSuffix(strings.TrimSuffix(string(line), "\n"), "\r")
+			if split {
+				s = continuation + s
+			}
+			l.log(level, s)
+		}
+		split = false
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
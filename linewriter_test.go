@@ -0,0 +1,38 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLineWriter(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", Llabel)
+	l.SetLevel(LevelWarn)
+	w := NewLineWriter(l, LevelWarn, "make: ")
+
+	// Lines and runes split across writes, and progress lines ended by
+	// carriage returns.
+	for _, p := range []string{"ab", "c\nd\xe2\x82", "\xac\n10%\r20%\r", "30%\r\n", "h\xc3", "\xa9", "\xe2", "\x82", "\xac", "llo\n", "tail"} {
+		if n, err := w.Write([]byte(p)); n != len(p) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", p, n, err)
+		}
+	}
+	w.Close()
+	want := "[WARN ] make: abc\n[WARN ] make: d€\n[WARN ] make: 30%\n[WARN ] make: hé€llo\n[WARN ] make: tail\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	// Every byte written separately.
+	buf.Reset()
+	w = NewLineWriter(l, LevelWarn, "")
+	for _, c := range []byte("ünïcödé ✓\n") {
+		w.Write([]byte{c})
+	}
+	w.Close()
+	if got := buf.String(); got != "[WARN ] ünïcödé ✓\n" || !utf8.ValidString(got) {
+		t.Errorf("output = %q", got)
+	}
+}
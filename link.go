@@ -0,0 +1,58 @@
+package log
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CallerLink returns the template of the hyperlinks around the caller in the
+// header, see SetCallerLink.
+func (l *Logger) CallerLink() string {
+	defer l.unlock(l.lock())
+	return l.clink
+}
+
+// SetCallerLink makes the caller in the header, as written with Lshortfile or
+// Llongfile, a hyperlink in terminals that support OSC 8 escape sequences, so
+// that clicking it opens the file. The template is the URL of the link, in
+// which {file} is replaced with the full path of the file and {line} with the
+// line number, for example
+//
+//	l.SetCallerLink("file://{file}")
+//	l.SetCallerLink("vscode://file/{file}:{line}")
+//
+// Links are only written to a terminal, and only if entries are written with
+// color, see ColorEnabled; NO_COLOR thus disables them too. Terminals without
+// support for OSC 8 show the caller as plain text. An empty template, the
+// default, disables the links.
+func (l *Logger) SetCallerLink(template string) {
+	defer l.unlock(l.lock())
+	l.clink = template
+}
+
+func CallerLink() string {
+	return std.CallerLink()
+}
+
+func SetCallerLink(template string) {
+	std.SetCallerLink(template)
+}
+
+// callerLink returns the URL of the hyperlink around the caller file:line in
+// the header, or "" if no link is written. It must be called with l.mu held.
+func (l *Logger) callerLink(file string, line int) string {
+	if l.clink == "" || !l.isTerm || !l.colored() {
+		return ""
+	}
+	r := strings.NewReplacer("{file}", file, "{line}", strconv.Itoa(line))
+	return r.Replace(l.clink)
+}
+
+// appendLink appends text to b as an OSC 8 hyperlink to url.
+func appendLink(b []byte, url, text string) []byte {
+	b = append(b, "\033]8;;"...)
+	b = append(b, url...)
+	b = append(b, "\033\\"...)
+	b = append(b, text...)
+	return append(b, "\033]8;;\033\\"...)
+}
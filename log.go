@@ -3,12 +3,19 @@
 package log
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"runtime"
+	"runtime/trace"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
-	golog "log"
+	"log/slog"
 
 	"golang.org/x/crypto/ssh/terminal"
 )
@@ -32,9 +39,31 @@ const (
 	LUTC                                   // if Ldate or Ltime is set, use UTC rather than the local time zone
 	Llabel                                 // log entry label: [DEBUG], [ERROR], [PANIC], ...
 	Lcolor                                 // colored output (if output is tty)
+	Lquote                                 // message as a Go-quoted string: "a \"quoted\" message"
+	Lsingleline                            // newlines in the message escaped as \n and \r. implied by Lquote
+	Ldelta                                 // time since the previous entry, before the message: (+12.4ms)
+	Lverboseerr                            // errors in the message and fields with detail: %+v, or the chain of wrapped errors
+	LJSON                                  // each entry as a JSON object on a single line: {"level":"info","msg":"message"}
+	LcolorAuto                             // colored output if the output is a terminal, see ColorEnabled
+	Llogfmt                                // each entry as logfmt key=value pairs: level=info msg=message
 	LstdFlags     = Ldate | Ltime | Llabel // initial values for the standard logger
 )
 
+// With LJSON, each entry is written as a JSON object on a line of its own, for
+// example
+//	{"time":"2009-01-23T01:23:23+01:00","level":"info","msg":"message","key":"value"}
+// The header is then written by this package rather than the standard logger,
+// and its parts become the keys time, level, prefix, logger, file and line;
+// the fields of the entry follow the msg key. Llabel, Lcolor, LcolorAuto,
+// Lquote, Lsingleline and Ldelta are ignored, and soft wrapping and
+// indentation are not applied.
+//
+// Llogfmt is similar, but writes each entry as logfmt key=value pairs, like
+//	ts=2009-01-23T01:23:23+01:00 level=info msg=message key=value
+// where the keys of the header are ts, level, prefix, logger, caller (as
+// file:line), code and msg. Values are quoted when needed, as for fields in
+// text output. LJSON takes precedence over Llogfmt.
+
 // Log levels.
 const (
 	LevelFatal   = iota       // Fatal log level
@@ -55,310 +84,766 @@ const (
 	colorYellow = 33
 	colorBlue   = 36
 	colorWhite  = 37
-
-	// ANSI escape sequence format
-	escSeq = "\033[%dm"
 )
 
-var (
-	// labelMap contains labels mapped to log levels.
-	labelMap = []string{
-		"FATAL",
-		"PANIC",
-		"ERROR",
-		"WARN ",
-		"INFO ",
-		"DEBUG",
-	}
-
-	// colorMap contains ANSI colors mapped to log levels.
-	colorMap = []int{
-		colorRed,
-		colorRed,
-		colorRed,
-		colorYellow,
-		colorBlue,
-		colorGreen,
-	}
-)
+// colorSeq returns the ANSI escape sequence that selects color.
+func colorSeq(color int) string {
+	return "\033[" + strconv.Itoa(color) + "m"
+}
+
+// singleLine escapes line breaks for the Lsingleline flag.
+var singleLine = strings.NewReplacer("\n", `\n`, "\r", `\r`)
+
+// traceMaxLen is the maximum length of a message emitted to the runtime tracer.
+const traceMaxLen = 1024
 
 // A Logger represents an active logging object that generates lines of
 // output to an io.Writer. Each logging operation makes a single call to
 // the Writer's Write method. A Logger can be used simultaneously from
 // multiple goroutines; it guarantees to serialize access to the Writer.
 type Logger struct {
-	l      *golog.Logger
+	*logger
+	every *throttle
+	with  []Field
+	comp  *component
+	depth atomic.Int32 // indentation depth, see Indent
+}
+
+// logger holds the state that a Logger shares with the loggers derived from it.
+type logger struct {
+	prefix atomic.Pointer[string]
 	mu     sync.Mutex
 	out    io.Writer
 	isTerm bool
 	flag   int
-	level  int
+	level  atomic.Int32
+	plevel atomic.Int32
+	rtrace bool
+	slog   slog.Handler
+	reg    *registry
+	health health
+	outs   atomic.Pointer[[]output]
+	stats  stats
+	cw     *countWriter
+	burst  *burst
+	now    func() time.Time
+	eol    string
+	utf8   int
+	indent string
+	clink  string
+	ccol   int
+	lcol   int
+	lnum   LevelNumbering
+	jfmt   JSONFormatter
+	redraw bool
+	global []Field
+	dyn    []dynamicField
+	human  bool
+	rep    *reporter
+	extra  entryExtra
+	diag   diag
+	owner  atomic.Int64
+	slow   time.Duration
+	retro  *retro
+	recent *retro
+	skip   int
+	wrap   bool
+	last   atomic.Int64
+	sumx   bool
+	route  [MaxLevel + 1]*countWriter
+	hooks  []*hook
+	mcolor int
+	autoc  bool
+	cdepth int
+	exitfn []func()
+	rdebug atomic.Bool
+	sample [MaxLevel + 1]*sampling
+	tees   []*tee
+	teeerr func(w io.Writer, err error)
 }
 
 // New returns a new Logger.
 func New(out io.Writer, prefix string, flag int) *Logger {
-	return &Logger{
-		l:      golog.New(out, prefix, flag),
+	cw := &countWriter{w: out}
+	l := &Logger{logger: &logger{
+		cw:     cw,
 		out:    out,
 		isTerm: isTerm(out),
+		autoc:  autoColor(out),
 		flag:   flag,
-		level:  LevelDefault,
-	}
-}
-
-// SetOutput sets the output destination for the logger.
+		now:    time.Now,
+		slow:   time.Second,
+		reg:    new(registry),
+	}}
+	l.prefix.Store(&prefix)
+	l.level.Store(LevelDefault)
+	l.plevel.Store(LevelInfo)
+	l.updateOutputs()
+	return l
+}
+
+// SetOutput sets the output destination for the logger. Outputs set for
+// individual levels with SetLevelOutput are kept.
 func (l *Logger) SetOutput(w io.Writer) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	defer l.unlock(l.lock())
 	l.out = w
 	l.isTerm = isTerm(w)
-	l.l.SetOutput(w)
-}
+	l.autoc = autoColor(w)
+	l.cw = &countWriter{w: w}
+	l.updateOutputs()
+}
+
+// format writes an entry with message s at the given level. It must be called
+// with l.mu held, directly by the method logging the entry, or with l.skip set
+// accordingly. It returns the error of writing the entry, if any.
+func (l *Logger) format(level int, s string) error {
+	extra := l.extra
+	l.extra = entryExtra{}
+	if l.owner.Load() != 0 {
+		l.internalf("recursive log call suppressed: %s", strings.TrimSuffix(s, "\n"))
+		return nil
+	}
+	if l.retro != nil && level <= LevelError {
+		l.replay()
+	}
+	if l.every != nil {
+		s = l.every.appendSuppressed(s)
+	}
+	fields := l.entryFields(extra)
+	defer l.leave(l.enter(l.callsBack(level, fields)))
+	msg := s
+	if len(fields) > 0 && l.needsText(level) {
+		s = appendMessage(s, string(appendFieldList(nil, fields, l)))
+	}
+	s = sanitizeUTF8(s, l.utf8)
+	if l.rep != nil && level <= l.rep.min {
+		l.report(level, s, fields, extra)
+	}
+	if l.recent != nil {
+		l.recent.add("[" + levelName(level) + "] " + s)
+	}
+	if l.rtrace && trace.IsEnabled() {
+		traceLog(extra.context(), level, s)
+	}
+	if l.burst != nil && level <= LevelError {
+		l.burst.observe(l.now())
+	}
+	if l.slog != nil {
+		l.handle(level, sanitizeUTF8(msg, l.utf8), fields, extra)
+		if len(l.hooks) > 0 {
+			l.runHooks(level, s, fields, extra)
+		}
+		return nil
+	}
+	text := s
+
+	var enc *[]byte
+	if l.flag&LJSON != 0 {
+		enc = getBuffer()
+		*enc = l.appendJSON(*enc, level, sanitizeUTF8(msg, l.utf8), fields, extra)
+	} else if l.flag&Llogfmt != 0 {
+		enc = getBuffer()
+		*enc = l.appendLogfmt(*enc, level, sanitizeUTF8(msg, l.utf8), fields, extra)
+	} else {
+		s = l.render(level, s, extra)
+		if l.flag&Ldelta != 0 {
+			s = l.delta(true) + s
+		}
+	}
 
-func (l *Logger) format(level int, s string) {
+	cw := l.levelWriter(level)
+	cw.level = level
+	start := time.Now()
+	var err error
+	if enc != nil {
+		_, err = cw.Write(*enc)
+		putBuffer(enc)
+	} else {
+		err = l.writeEntry(cw, l.flag, l.Prefix(), 3+l.skip, s)
+	}
+	l.timeWrite(start)
+	l.health.record(err)
+	if err != nil {
+		l.internalf("writing entry: %v", err)
+	} else {
+		l.stats.add(level, cw.last.Load())
+	}
+	if len(l.tees) > 0 {
+		l.writeTees(level, text, msg, fields, extra)
+	}
+	if level <= LevelPanic {
+		// The program is about to exit or panic.
+		l.flushOutput(cw.w)
+		for _, t := range l.tees {
+			l.flushOutput(t.w)
+		}
+	}
+	if len(l.hooks) > 0 {
+		l.runHooks(level, text, fields, extra)
+	}
+	return err
+}
+
+// needsText reports whether format needs the text of an entry at level, with
+// its fields rendered as for text output, rather than only the encoding for
+// LJSON or Llogfmt.
+func (l *Logger) needsText(level int) bool {
+	return l.flag&(LJSON|Llogfmt) == 0 || (l.rep != nil && level <= l.rep.min) ||
+		l.recent != nil || l.rtrace || l.slog != nil || len(l.tees) > 0 || len(l.hooks) > 0
+}
+
+// render applies the flags and settings of l to the message s of an entry,
+// which is then ready to be written after the header. It must be called by
+// format or sprintEntry.
+func (l *Logger) render(level int, s string, extra entryExtra) string {
+	if l.flag&Lquote != 0 {
+		// The quoted message is a single line, outside any color codes.
+		s = strconv.Quote(strings.TrimSuffix(s, "\n"))
+	} else if l.flag&Lsingleline != 0 {
+		s = singleLine.Replace(strings.TrimSuffix(s, "\n"))
+	}
+	if l.comp != nil {
+		s = l.comp.name + ": " + s
+	}
+	if extra.code != "" {
+		s = "[" + extra.code + "] " + s
+	}
+	if depth := int(l.depth.Load()); depth > 0 {
+		s = indentLines(s, l.indent, depth)
+	}
+	if l.eol != "" {
+		s = strings.ReplaceAll(strings.TrimSuffix(s, "\n"), "\r\n", "\n")
+		s = strings.ReplaceAll(s, "\n", l.eol)
+	}
+	color := l.colored()
+	if color && l.mcolor != colorNone {
+		s = appendMessage(colorSeq(l.mcolor)+s, colorSeq(colorNone))
+	}
 	if l.flag&Llabel != 0 {
-		label := labelMap[level]
-
-		if l.flag&Lcolor != 0 {
-			color := colorMap[level]
-			s = fmt.Sprintf("["+escSeq+"%s"+escSeq+"] "+escSeq+"%s"+escSeq, color, label, colorNone, colorWhite, s, colorNone)
-		} else {
-			s = fmt.Sprintf("[%s] %s", label, s)
+		label := levelLabel(level)
+		if n := l.lcol - len(label); n > 0 {
+			label += strings.Repeat(" ", n)
 		}
+		if color {
+			label = colorSeq(levelColor(level)) + label + colorSeq(colorNone)
+		}
+		s = "[" + label + "] " + s
+	}
+	if l.wrap && l.isTerm {
+		nl := "\n"
+		if l.eol != "" {
+			nl = l.eol
+		}
+		s = l.softWrap(s, nl)
+	}
+	if l.eol != "" {
+		s += l.eol
 	}
+	return s
+}
+
+// Enabled reports whether entries at the given level are logged by l, so that
+// expensive arguments can be skipped when they are not:
+//
+//	if logger.Enabled(log.LevelDebug) {
+//		logger.Debugf("state: %v", dumpState())
+//	}
+//
+// It does not lock l. Entries may still be dropped by a Logger returned by
+// Every.
+func (l *Logger) Enabled(level int) bool {
+	return l.threshold() >= level && l.slogEnabled(level)
+}
+
+func Enabled(level int) bool {
+	return std.Enabled(level)
+}
 
-	l.l.Output(3, s)
+// suppressed reports whether entries at the given level are not logged, nor
+// kept for retroactive debugging, so that the method logging an entry can
+// return without locking l.
+func (l *Logger) suppressed(level int) bool {
+	if l.threshold() < level {
+		return level != LevelDebug || !l.rdebug.Load()
+	}
+	return !l.slogEnabled(level)
 }
 
+// enabled reports whether an entry at the given level should be logged. It
+// must be called with l.mu held, directly by the method logging the entry.
+func (l *Logger) enabled(level int) bool {
+	if l.threshold() < level || !l.slogEnabled(level) {
+		return false
+	}
+	if level <= LevelPanic {
+		return true
+	}
+	sm := l.sample[level]
+	if l.every == nil && sm == nil {
+		return true
+	}
+	var pc [1]uintptr
+	runtime.Callers(3+l.skip, pc[:]) // skip Callers, enabled and the logging method
+	if sm != nil && !l.sampled(level, sm, pc[0]) {
+		return false
+	}
+	if l.every != nil {
+		return l.every.allow(pc[0], l.now())
+	}
+	return true
+}
+
+// log formats s at the given level, if that level is enabled.
+func (l *Logger) log(level int, s string) {
+	if l.suppressed(level) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(level) {
+		l.format(level, s)
+	}
+}
+
+// ColoredOutput reports whether Lcolor is set and the output is a terminal.
+// Use ColorEnabled to find out whether entries are written with color.
 func (l *Logger) ColoredOutput() bool {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	return l.isTerm && l.flag&Lcolor != 0
+	defer l.unlock(l.lock())
+	return l.isTerm && l.flag&(Lcolor|LJSON|Llogfmt) == Lcolor
 }
 
+// Output writes an entry at the print level, see SetPrintLevel, if that level
+// is enabled, in the same way as Print. The calldepth is the number of stack
+// frames to skip when determining the caller in the header, in addition to
+// those set with SetCallDepth; 1 selects the caller of Output. It returns the
+// error of writing the entry, if any.
 func (l *Logger) Output(calldepth int, s string) error {
-	return l.l.Output(calldepth+1, s)
+	defer l.unlock(l.lock())
+	return l.output(calldepth, l.PrintLevel(), s)
+}
+
+// OutputLevel is like Output, but writes the entry at the given level. Like
+// Log, it does not exit or panic at the fatal and panic levels. It panics if
+// level is not registered.
+func (l *Logger) OutputLevel(calldepth, level int, s string) error {
+	if !validLevel(level) {
+		panic("invalid log level")
+	}
+	defer l.unlock(l.lock())
+	return l.output(calldepth, level, s)
+}
+
+// output implements Output and OutputLevel. It must be called directly by
+// them, with l.mu held.
+func (l *Logger) output(calldepth, level int, s string) error {
+	skip := l.skip
+	l.skip += calldepth
+	defer func() { l.skip = skip }()
+	if !l.enabled(level) {
+		return nil
+	}
+	return l.format(level, s)
+}
+
+// CallDepth returns the number of additional stack frames skipped to determine
+// the caller, see SetCallDepth.
+func (l *Logger) CallDepth() int {
+	defer l.unlock(l.lock())
+	return l.cdepth
+}
+
+// SetCallDepth sets the number of additional stack frames to skip when
+// determining the caller of a logging method, for the header of an entry, the
+// Entry passed to hooks and error reporters, and the call sites of Every. This
+// is needed when l is only used through wrapper functions: for example, with
+// depth 1 the caller of the function that calls l is reported, rather than
+// that function itself. The setting is shared with the loggers derived from l.
+func (l *Logger) SetCallDepth(depth int) {
+	defer l.unlock(l.lock())
+	l.cdepth = depth
 }
 
 func (l *Logger) Write(p []byte) (n int, err error) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	return l.out.Write(p)
+	defer l.unlock(l.lock())
+	if l.owner.Load() != 0 {
+		l.internalf("recursive write suppressed: %q", p)
+		return len(p), nil
+	}
+	defer l.leave(l.enter(!inert(l.out)))
+	start := time.Now()
+	n, err = l.out.Write(p)
+	l.timeWrite(start)
+	l.health.record(err)
+	l.cw.health.record(err)
+	if err != nil {
+		l.internalf("writing: %v", err)
+	}
+	return
 }
 
 func (l *Logger) Print(v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelInfo {
-		l.format(LevelInfo, fmt.Sprint(v...))
+	level := l.PrintLevel()
+	if l.suppressed(level) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(level) {
+		l.format(level, l.sprint(v...))
 	}
 }
 
 func (l *Logger) Println(v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelInfo {
-		l.format(LevelInfo, fmt.Sprintln(v...))
+	level := l.PrintLevel()
+	if l.suppressed(level) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(level) {
+		l.format(level, l.sprintln(v...))
 	}
 }
 
 func (l *Logger) Printf(format string, v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelInfo {
-		l.format(LevelInfo, fmt.Sprintf(format, v...))
+	level := l.PrintLevel()
+	if l.suppressed(level) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(level) {
+		l.format(level, l.sprintf(format, v...))
 	}
 }
 
 func (l *Logger) Fatal(v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelFatal {
-		l.format(LevelFatal, fmt.Sprint(v...))
+	locked := l.lock()
+	if l.enabled(LevelFatal) {
+		l.format(LevelFatal, l.sprint(v...))
 	}
-	os.Exit(1)
+	l.fatalExit(locked, 1)
+}
+
+// FatalCode is like Fatal, but exits with the given code rather than 1.
+func (l *Logger) FatalCode(code int, v ...interface{}) {
+	locked := l.lock()
+	if l.enabled(LevelFatal) {
+		l.format(LevelFatal, l.sprint(v...))
+	}
+	l.fatalExit(locked, code)
 }
 
 func (l *Logger) Fatalln(v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelFatal {
-		l.format(LevelFatal, fmt.Sprintln(v...))
+	locked := l.lock()
+	if l.enabled(LevelFatal) {
+		l.format(LevelFatal, l.sprintln(v...))
 	}
-	os.Exit(1)
+	l.fatalExit(locked, 1)
 }
 
 func (l *Logger) Fatalf(format string, v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelFatal {
-		l.format(LevelFatal, fmt.Sprintf(format, v...))
+	locked := l.lock()
+	if l.enabled(LevelFatal) {
+		l.format(LevelFatal, l.sprintf(format, v...))
 	}
-	os.Exit(1)
+	l.fatalExit(locked, 1)
 }
 
 func (l *Logger) Panic(v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	s := fmt.Sprint(v...)
-	if l.level >= LevelPanic {
+	defer l.unlock(l.lock())
+	s := l.sprint(v...)
+	if l.enabled(LevelPanic) {
 		l.format(LevelPanic, s)
 	}
 	panic(s)
 }
 
 func (l *Logger) Panicln(v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	s := fmt.Sprintln(v...)
-	if l.level >= LevelPanic {
+	defer l.unlock(l.lock())
+	s := l.sprintln(v...)
+	if l.enabled(LevelPanic) {
 		l.format(LevelPanic, s)
 	}
 	panic(s)
 }
 
 func (l *Logger) Panicf(format string, v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	s := fmt.Sprintf(format, v...)
-	if l.level >= LevelPanic {
+	defer l.unlock(l.lock())
+	s := l.sprintf(format, v...)
+	if l.enabled(LevelPanic) {
 		l.format(LevelPanic, s)
 	}
 	panic(s)
 }
 
 func (l *Logger) Error(v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelError {
-		l.format(LevelError, fmt.Sprint(v...))
+	if l.suppressed(LevelError) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelError) {
+		l.format(LevelError, l.sprint(v...))
 	}
 }
 
 func (l *Logger) Errorln(v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelError {
-		l.format(LevelError, fmt.Sprintln(v...))
+	if l.suppressed(LevelError) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelError) {
+		l.format(LevelError, l.sprintln(v...))
 	}
 }
 
 func (l *Logger) Errorf(format string, v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelError {
-		l.format(LevelError, fmt.Sprintf(format, v...))
+	if l.suppressed(LevelError) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelError) {
+		l.format(LevelError, l.sprintf(format, v...))
+	}
+}
+
+// Errort logs a message template at error level. Each {name} placeholder in the
+// template is replaced by the value of the field with that name; placeholders
+// without a field are left as they are and remaining fields are appended as
+// key=value pairs. With LJSON or Llogfmt, all fields are also written as fields
+// of the entry, after the rendered message.
+func (l *Logger) Errort(template string, fields Fields) {
+	if l.suppressed(LevelError) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelError) {
+		l.format(LevelError, l.template(template, fields))
 	}
 }
 
+// ErrE formats according to a format specifier, logs the result at error level
+// and returns it as an error. The error is the one fmt.Errorf would return, so
+// the %w verb can be used to wrap errors. The logged message is formatted like
+// that of Errorf, with LogValuers resolved and the fields of the errors among v
+// added to the entry.
+func (l *Logger) ErrE(format string, v ...interface{}) error {
+	err := fmt.Errorf(format, v...)
+	defer l.unlock(l.lock())
+	if l.enabled(LevelError) {
+		msg := l.sprintf(errorfFormat(format), v...)
+		l.extra.err = err
+		l.format(LevelError, msg)
+	}
+	return err
+}
+
+// Wrap logs msg and err at error level and returns err wrapped with msg, as
+// fmt.Errorf("%s: %w", msg, err) would. If err is nil, Wrap logs nothing and
+// returns nil.
+func (l *Logger) Wrap(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	err = fmt.Errorf("%s: %w", msg, err)
+	defer l.unlock(l.lock())
+	if l.enabled(LevelError) {
+		l.extra.err = err
+		l.format(LevelError, err.Error())
+	}
+	return err
+}
+
+// Wrapf is like Wrap, but formats the message according to a format specifier.
+func (l *Logger) Wrapf(err error, format string, v ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	// The message is formatted with the logger locked, as the fields of
+	// the errors among v are added to the entry.
+	defer l.unlock(l.lock())
+	err = fmt.Errorf("%s: %w", l.sprintf(format, v...), err)
+	if l.enabled(LevelError) {
+		l.extra.err = err
+		l.format(LevelError, err.Error())
+	}
+	return err
+}
+
 func (l *Logger) Warn(v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelWarn {
-		l.format(LevelWarn, fmt.Sprint(v...))
+	if l.suppressed(LevelWarn) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelWarn) {
+		l.format(LevelWarn, l.sprint(v...))
 	}
 }
 
 func (l *Logger) Warnln(v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelWarn {
-		l.format(LevelWarn, fmt.Sprintln(v...))
+	if l.suppressed(LevelWarn) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelWarn) {
+		l.format(LevelWarn, l.sprintln(v...))
 	}
 }
 
 func (l *Logger) Warnf(format string, v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelWarn {
-		l.format(LevelWarn, fmt.Sprintf(format, v...))
+	if l.suppressed(LevelWarn) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelWarn) {
+		l.format(LevelWarn, l.sprintf(format, v...))
+	}
+}
+
+// Warnt is like Errort, but logs at warn level.
+func (l *Logger) Warnt(template string, fields Fields) {
+	if l.suppressed(LevelWarn) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelWarn) {
+		l.format(LevelWarn, l.template(template, fields))
 	}
 }
 
 func (l *Logger) Info(v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelInfo {
-		l.format(LevelInfo, fmt.Sprint(v...))
+	if l.suppressed(LevelInfo) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelInfo) {
+		l.format(LevelInfo, l.sprint(v...))
 	}
 }
 
 func (l *Logger) Infoln(v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelInfo {
-		l.format(LevelInfo, fmt.Sprintln(v...))
+	if l.suppressed(LevelInfo) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelInfo) {
+		l.format(LevelInfo, l.sprintln(v...))
 	}
 }
 
 func (l *Logger) Infof(format string, v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelInfo {
-		l.format(LevelInfo, fmt.Sprintf(format, v...))
+	if l.suppressed(LevelInfo) {
+		return
 	}
-}
-
-func (l *Logger) Debug(v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelDebug {
-		l.format(LevelDebug, fmt.Sprint(v...))
+	defer l.unlock(l.lock())
+	if l.enabled(LevelInfo) {
+		l.format(LevelInfo, l.sprintf(format, v...))
 	}
 }
 
-func (l *Logger) Debugln(v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelDebug {
-		l.format(LevelDebug, fmt.Sprintln(v...))
+// Infot is like Errort, but logs at info level.
+func (l *Logger) Infot(template string, fields Fields) {
+	if l.suppressed(LevelInfo) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelInfo) {
+		l.format(LevelInfo, l.template(template, fields))
 	}
 }
 
-func (l *Logger) Debugf(format string, v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.level >= LevelDebug {
-		l.format(LevelDebug, fmt.Sprintf(format, v...))
+// InfoFunc logs the message returned by fn at info level. Fn is only called if
+// info level is enabled, so it can build messages that are costly to produce.
+// A panic in fn is recovered and logged as a placeholder message.
+func (l *Logger) InfoFunc(fn func() string) {
+	if l.suppressed(LevelInfo) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelInfo) {
+		l.format(LevelInfo, l.sprintFunc(fn))
 	}
 }
 
 func (l *Logger) Flags() (v int) {
-	l.mu.Lock()
-	v = l.flag
-	l.mu.Unlock()
-	return
+	defer l.unlock(l.lock())
+	return l.flag
 }
 
 func (l *Logger) SetFlags(flag int) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	defer l.unlock(l.lock())
 	l.flag = flag
-	l.l.SetFlags(flag)
 }
 
-func (l *Logger) Level() (v int) {
-	l.mu.Lock()
-	v = l.level
-	l.mu.Unlock()
-	return
+func (l *Logger) Level() int {
+	return l.threshold()
 }
 
+// SetLevel sets the log level of the logger. Entries above it are not logged.
+// For a logger returned by Named, it sets the level for its name, as by
+// SetLevelFor. It panics if level is not a built-in level or one registered
+// with RegisterLevel.
 func (l *Logger) SetLevel(level int) {
-	if level > LevelDebug {
+	if !validLevel(level) {
 		panic("invalid log level")
 	}
+	if l.comp != nil {
+		l.comp.level.Store(int64(level))
+		return
+	}
+	l.level.Store(int32(level))
+}
+
+// PrintLevel returns the log level used by the Print functions.
+func (l *Logger) PrintLevel() int {
+	return int(l.plevel.Load())
+}
+
+// SetPrintLevel sets the log level used by the Print functions, for both
+// filtering and labeling. The default is LevelInfo.
+func (l *Logger) SetPrintLevel(level int) {
+	if !validLevel(level) {
+		panic("invalid log level")
+	}
+	l.plevel.Store(int32(level))
+}
+
+// LineEnding returns the line ending of the logger.
+func (l *Logger) LineEnding() string {
+	defer l.unlock(l.lock())
+	if l.eol == "" {
+		return "\n"
+	}
+	return l.eol
+}
+
+// SetLineEnding sets the line ending used to terminate each line of an entry,
+// such as "\r\n". It must end with "\n". The default is "\n".
+func (l *Logger) SetLineEnding(eol string) {
+	if !strings.HasSuffix(eol, "\n") {
+		panic("invalid line ending")
+	}
+	if eol == "\n" {
+		eol = ""
+	}
+	l.mu.Lock()
+	l.eol = eol
+	l.mu.Unlock()
+}
+
+// EnableRuntimeTrace sets whether entries are also emitted to the runtime
+// tracer, so that they show up in the timeline of go tool trace. This only
+// has effect while tracing is active. Entries logged by a *Ctx method, like
+// InfoCtx, are emitted with their context, which associates them with the
+// trace task of the context.
+func (l *Logger) EnableRuntimeTrace(enable bool) {
 	l.mu.Lock()
-	l.level = level
+	l.rtrace = enable
 	l.mu.Unlock()
 }
 
 func (l *Logger) Prefix() string {
-	return l.l.Prefix()
+	return *l.prefix.Load()
 }
 
 func (l *Logger) SetPrefix(prefix string) {
-	l.l.SetPrefix(prefix)
+	l.prefix.Store(&prefix)
 }
 
 // Standard logger
@@ -368,188 +853,359 @@ func StdLogger() *Logger {
 	return std
 }
 
+// SetStdLogger replaces the standard logger, which is used by the package-level
+// functions, by l. It is meant for tests and program initialization, and must
+// not be called while the package-level functions are in use.
+func SetStdLogger(l *Logger) {
+	std = l
+}
+
+// exit is called by the Fatal functions and methods after logging.
+var exit = os.Exit
+
+// SetExitFunc sets the function called by the Fatal functions and methods after
+// logging the entry, os.Exit by default, and returns the previous one. A nil fn
+// restores os.Exit. Like SetStdLogger, it is meant for tests: if fn returns,
+// so does the Fatal call.
+func SetExitFunc(fn func(code int)) (prev func(code int)) {
+	prev = exit
+	if fn == nil {
+		fn = os.Exit
+	}
+	exit = fn
+	return prev
+}
+
+// fatalExit is called by the Fatal methods after logging, with the result of
+// lock. It logs the summary if SummaryOnExit is enabled, and unlocks l. It then
+// flushes the error reporter and hooks, runs the exit handlers and exits with
+// the given code.
+func (l *Logger) fatalExit(locked bool, code int) {
+	if l.sumx {
+		l.format(LevelInfo, l.summary())
+	}
+	rep, hooks, handlers, out := l.rep, l.hooks, l.exitfn, l.out
+	l.unlock(locked)
+
+	l.flushOutput(out)
+	rep.flush()
+	flushHooks(hooks)
+	for _, fn := range handlers {
+		l.runExitHandler(fn)
+	}
+	exit(code)
+}
+
+// RegisterExitHandler registers fn to be called by the Fatal methods of l and
+// the loggers derived from it, after the fatal entry is written and before the
+// program exits, for example to flush buffered output or close connections.
+// Handlers are called in the order they were registered, without l being
+// locked, so they may log. A panic in a handler is recovered and reported on
+// the internal output, and the remaining handlers are still called.
+func (l *Logger) RegisterExitHandler(fn func()) {
+	defer l.unlock(l.lock())
+	l.exitfn = append(l.exitfn[:len(l.exitfn):len(l.exitfn)], fn)
+}
+
+func RegisterExitHandler(fn func()) {
+	std.RegisterExitHandler(fn)
+}
+
+func (l *Logger) runExitHandler(fn func()) {
+	defer func() {
+		if p := recover(); p != nil {
+			l.internalf("recovered from panic in exit handler: %s", describePanic(p))
+		}
+	}()
+	fn()
+}
+
 func SetOutput(w io.Writer) {
 	std.SetOutput(w)
 }
 
 func Output(calldepth int, s string) error {
-	return std.l.Output(calldepth+1, s)
+	defer std.unlock(std.lock())
+	return std.output(calldepth, std.PrintLevel(), s)
+}
+
+func OutputLevel(calldepth, level int, s string) error {
+	if !validLevel(level) {
+		panic("invalid log level")
+	}
+	defer std.unlock(std.lock())
+	return std.output(calldepth, level, s)
+}
+
+func CallDepth() int {
+	return std.CallDepth()
+}
+
+func SetCallDepth(depth int) {
+	std.SetCallDepth(depth)
 }
 
 func Print(v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelInfo {
-		std.format(LevelInfo, fmt.Sprint(v...))
+	level := std.PrintLevel()
+	if std.suppressed(level) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(level) {
+		std.format(level, std.sprint(v...))
 	}
 }
 
 func Println(v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelInfo {
-		std.format(LevelInfo, fmt.Sprintln(v...))
+	level := std.PrintLevel()
+	if std.suppressed(level) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(level) {
+		std.format(level, std.sprintln(v...))
 	}
 }
 
 func Printf(format string, v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelInfo {
-		std.format(LevelInfo, fmt.Sprintf(format, v...))
+	level := std.PrintLevel()
+	if std.suppressed(level) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(level) {
+		std.format(level, std.sprintf(format, v...))
 	}
 }
 
 func Fatal(v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelFatal {
-		std.format(LevelFatal, fmt.Sprint(v...))
+	locked := std.lock()
+	if std.enabled(LevelFatal) {
+		std.format(LevelFatal, std.sprint(v...))
 	}
-	os.Exit(1)
+	std.fatalExit(locked, 1)
+}
+
+func FatalCode(code int, v ...interface{}) {
+	locked := std.lock()
+	if std.enabled(LevelFatal) {
+		std.format(LevelFatal, std.sprint(v...))
+	}
+	std.fatalExit(locked, code)
 }
 
 func Fatalln(v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelFatal {
-		std.format(LevelFatal, fmt.Sprintln(v...))
+	locked := std.lock()
+	if std.enabled(LevelFatal) {
+		std.format(LevelFatal, std.sprintln(v...))
 	}
-	os.Exit(1)
+	std.fatalExit(locked, 1)
 }
 
 func Fatalf(format string, v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelFatal {
-		std.format(LevelFatal, fmt.Sprintf(format, v...))
+	locked := std.lock()
+	if std.enabled(LevelFatal) {
+		std.format(LevelFatal, std.sprintf(format, v...))
 	}
-	os.Exit(1)
+	std.fatalExit(locked, 1)
 }
 
 func Panic(v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	s := fmt.Sprint(v...)
-	if std.level >= LevelPanic {
+	defer std.unlock(std.lock())
+	s := std.sprint(v...)
+	if std.enabled(LevelPanic) {
 		std.format(LevelPanic, s)
 	}
 	panic(s)
 }
 
 func Panicln(v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	s := fmt.Sprintln(v...)
-	if std.level >= LevelPanic {
+	defer std.unlock(std.lock())
+	s := std.sprintln(v...)
+	if std.enabled(LevelPanic) {
 		std.format(LevelPanic, s)
 	}
 	panic(s)
 }
 
 func Panicf(format string, v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	s := fmt.Sprintf(format, v...)
-	if std.level >= LevelPanic {
+	defer std.unlock(std.lock())
+	s := std.sprintf(format, v...)
+	if std.enabled(LevelPanic) {
 		std.format(LevelPanic, s)
 	}
 	panic(s)
 }
 
 func Error(v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelError {
-		std.format(LevelError, fmt.Sprint(v...))
+	if std.suppressed(LevelError) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelError) {
+		std.format(LevelError, std.sprint(v...))
 	}
 }
 
 func Errorln(v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelError {
-		std.format(LevelError, fmt.Sprintln(v...))
+	if std.suppressed(LevelError) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelError) {
+		std.format(LevelError, std.sprintln(v...))
 	}
 }
 
 func Errorf(format string, v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelError {
-		std.format(LevelError, fmt.Sprintf(format, v...))
+	if std.suppressed(LevelError) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelError) {
+		std.format(LevelError, std.sprintf(format, v...))
+	}
+}
+
+func Errort(template string, fields Fields) {
+	if std.suppressed(LevelError) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelError) {
+		std.format(LevelError, std.template(template, fields))
+	}
+}
+
+// ErrE formats according to a format specifier, logs the result at error level
+// using the standard logger and returns it as an error.
+func ErrE(format string, v ...interface{}) error {
+	err := fmt.Errorf(format, v...)
+	defer std.unlock(std.lock())
+	if std.enabled(LevelError) {
+		msg := std.sprintf(errorfFormat(format), v...)
+		std.extra.err = err
+		std.format(LevelError, msg)
+	}
+	return err
+}
+
+// Wrap logs msg and err at error level using the standard logger and returns
+// err wrapped with msg. If err is nil, Wrap logs nothing and returns nil.
+func Wrap(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	err = fmt.Errorf("%s: %w", msg, err)
+	defer std.unlock(std.lock())
+	if std.enabled(LevelError) {
+		std.extra.err = err
+		std.format(LevelError, err.Error())
+	}
+	return err
+}
+
+// Wrapf is like Wrap, but formats the message according to a format specifier.
+func Wrapf(err error, format string, v ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	defer std.unlock(std.lock())
+	err = fmt.Errorf("%s: %w", std.sprintf(format, v...), err)
+	if std.enabled(LevelError) {
+		std.extra.err = err
+		std.format(LevelError, err.Error())
 	}
+	return err
 }
 
 func Warn(v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelWarn {
-		std.format(LevelWarn, fmt.Sprint(v...))
+	if std.suppressed(LevelWarn) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelWarn) {
+		std.format(LevelWarn, std.sprint(v...))
 	}
 }
 
 func Warnln(v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelWarn {
-		std.format(LevelWarn, fmt.Sprintln(v...))
+	if std.suppressed(LevelWarn) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelWarn) {
+		std.format(LevelWarn, std.sprintln(v...))
 	}
 }
 
 func Warnf(format string, v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelWarn {
-		std.format(LevelWarn, fmt.Sprintf(format, v...))
+	if std.suppressed(LevelWarn) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelWarn) {
+		std.format(LevelWarn, std.sprintf(format, v...))
+	}
+}
+
+func Warnt(template string, fields Fields) {
+	if std.suppressed(LevelWarn) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelWarn) {
+		std.format(LevelWarn, std.template(template, fields))
 	}
 }
 
 func Info(v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelInfo {
-		std.format(LevelInfo, fmt.Sprint(v...))
+	if std.suppressed(LevelInfo) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelInfo) {
+		std.format(LevelInfo, std.sprint(v...))
 	}
 }
 
 func Infoln(v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelInfo {
-		std.format(LevelInfo, fmt.Sprintln(v...))
+	if std.suppressed(LevelInfo) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelInfo) {
+		std.format(LevelInfo, std.sprintln(v...))
 	}
 }
 
 func Infof(format string, v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelInfo {
-		std.format(LevelInfo, fmt.Sprintf(format, v...))
+	if std.suppressed(LevelInfo) {
+		return
 	}
-}
-
-func Debug(v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelDebug {
-		std.format(LevelDebug, fmt.Sprint(v...))
+	defer std.unlock(std.lock())
+	if std.enabled(LevelInfo) {
+		std.format(LevelInfo, std.sprintf(format, v...))
 	}
 }
 
-func Debugln(v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelDebug {
-		std.format(LevelDebug, fmt.Sprintln(v...))
+func Infot(template string, fields Fields) {
+	if std.suppressed(LevelInfo) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelInfo) {
+		std.format(LevelInfo, std.template(template, fields))
 	}
 }
 
-func Debugf(format string, v ...interface{}) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	if std.level >= LevelDebug {
-		std.format(LevelDebug, fmt.Sprintf(format, v...))
+func InfoFunc(fn func() string) {
+	if std.suppressed(LevelInfo) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelInfo) {
+		std.format(LevelInfo, std.sprintFunc(fn))
 	}
 }
 
@@ -573,6 +1229,42 @@ func SetLevel(level int) {
 	std.SetLevel(level)
 }
 
+func PrintLevel() int {
+	return std.PrintLevel()
+}
+
+func SetPrintLevel(level int) {
+	std.SetPrintLevel(level)
+}
+
+func LineEnding() string {
+	return std.LineEnding()
+}
+
+func SetLineEnding(eol string) {
+	std.SetLineEnding(eol)
+}
+
+func EnableRuntimeTrace(enable bool) {
+	std.EnableRuntimeTrace(enable)
+}
+
+func Health() HealthStatus {
+	return std.Health()
+}
+
+func ResetHealth() {
+	std.ResetHealth()
+}
+
+func Stats() LevelStats {
+	return std.Stats()
+}
+
+func ResetStats() {
+	std.ResetStats()
+}
+
 func Prefix() string {
 	return std.Prefix()
 }
@@ -581,6 +1273,97 @@ func SetPrefix(prefix string) {
 	std.SetPrefix(prefix)
 }
 
+// traceLog emits message s to the runtime tracer, with category "log." followed
+// by the lowercase level name, in the trace task of ctx.
+func traceLog(ctx context.Context, level int, s string) {
+	if len(s) > traceMaxLen {
+		s = s[:traceMaxLen]
+	}
+	category := "log." + strings.ToLower(levelName(level))
+	trace.Log(ctx, category, s)
+}
+
+// sprint, sprintln and sprintf format the message of an entry. They are like
+// their fmt counterparts, but resolve arguments that implement LogValuer,
+// collect the fields of errors that implement LogFielder, and recover from
+// panics raised while formatting the arguments, so that logging never crashes
+// the program. The output is then replaced with a placeholder.
+func (l *Logger) sprint(v ...interface{}) (s string) {
+	defer l.recoverFormat(&s)
+	l.collectErrorFields(v)
+	return fmt.Sprint(l.verboseErrors(l.resolveAll(v))...)
+}
+
+func (l *Logger) sprintln(v ...interface{}) (s string) {
+	defer l.recoverFormat(&s)
+	l.collectErrorFields(v)
+	return fmt.Sprintln(l.verboseErrors(l.resolveAll(v))...)
+}
+
+func (l *Logger) sprintf(format string, v ...interface{}) (s string) {
+	defer l.recoverFormat(&s)
+	l.collectErrorFields(v)
+	return fmt.Sprintf(format, l.verboseErrors(l.resolveAll(v))...)
+}
+
+// errorfFormat returns format with its %w verbs, which only fmt.Errorf
+// accepts, replaced by %v.
+func errorfFormat(format string) string {
+	if !strings.Contains(format, "%") || !strings.Contains(format, "w") {
+		return format
+	}
+	b := []byte(format)
+	for i := 0; i < len(b); i++ {
+		if b[i] != '%' {
+			continue
+		}
+		// Skip the flags, width, precision and argument index.
+		for i++; i < len(b) && strings.IndexByte("+-# 0123456789.[]*", b[i]) >= 0; i++ {
+		}
+		if i < len(b) && b[i] == 'w' {
+			b[i] = 'v'
+		}
+	}
+	return string(b)
+}
+
+// sprintValue formats a single value, recovering from panics like sprint.
+func (l *Logger) sprintValue(v interface{}) (s string) {
+	defer l.recoverFormat(&s)
+	return fmt.Sprint(v)
+}
+
+// sprintFunc returns the result of fn, recovering from panics like sprint.
+func (l *Logger) sprintFunc(fn func() string) (s string) {
+	defer l.recoverFormat(&s)
+	return fn()
+}
+
+func (l *Logger) recoverFormat(s *string) {
+	if p := recover(); p != nil {
+		*s = "!PANIC(" + describePanic(p) + ")"
+		l.internalf("recovered from panic while formatting entry: %s", *s)
+	}
+}
+
+// describePanic describes the panic value p as "type: message". It is careful
+// not to panic itself while doing so.
+func describePanic(p interface{}) (s string) {
+	s = fmt.Sprintf("%T", p)
+	defer func() {
+		recover()
+	}()
+	switch v := p.(type) {
+	case error:
+		return s + ": " + v.Error()
+	case fmt.Stringer:
+		return s + ": " + v.String()
+	case string:
+		return s + ": " + v
+	}
+	return s + ": " + fmt.Sprint(p)
+}
+
 func isTerm(out io.Writer) bool {
 	file, ok := out.(interface {
 		Fd() uintptr
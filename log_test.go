@@ -0,0 +1,228 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentSetLevel(t *testing.T) {
+	l := New(io.Discard, "", Llabel)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				l.SetLevel(j % (LevelDebug + 1))
+				l.SetPrintLevel(LevelWarn)
+				l.Debugf("debug %d", j)
+				l.Info("info")
+				l.Print("print")
+				_ = l.Enabled(LevelInfo)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestOutput(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", Lshortfile|Llabel)
+	l.SetLevel(LevelInfo)
+
+	_, _, line, _ := runtime.Caller(0)
+	l.Output(1, "out")
+	l.OutputLevel(1, LevelDebug, "hidden")
+	l.OutputLevel(1, LevelWarn, "warn")
+	l.SetPrintLevel(LevelDebug)
+	l.Output(1, "hidden")
+	want := fmt.Sprintf("log_test.go:%d: [INFO ] out\n", line+1) +
+		fmt.Sprintf("log_test.go:%d: [WARN ] warn\n", line+3)
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestPrintLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", Llabel)
+	l.SetLevel(LevelInfo)
+
+	l.Print("info")
+	l.SetPrintLevel(LevelWarn)
+	l.Printf("warn %d", 1)
+	l.Output(1, "warn output")
+	l.SetPrintLevel(LevelDebug)
+	l.Println("hidden")
+	l.Output(1, "hidden")
+	l.SetLevel(LevelDebug)
+	l.Print("debug")
+	want := "[INFO ] info\n[WARN ] warn 1\n[WARN ] warn output\n[DEBUG] debug\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if got := l.Clone().PrintLevel(); got != LevelDebug {
+		t.Errorf("print level of clone = %d, want %d", got, LevelDebug)
+	}
+}
+
+func TestSingleLine(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", Llabel|Lsingleline)
+
+	// Stack traces and fields end up on the single line too, and Lsingleline
+	// wins over indentation.
+	func() {
+		defer l.Recover("worker")
+		panic("boom")
+	}()
+	l.Indent()
+	l.Errorw("multi\r\nline\n", "k", "v\nw")
+	entries := strings.SplitAfter(buf.String(), "\n")
+	if len(entries) != 3 || entries[2] != "" {
+		t.Fatalf("output %q is not two lines", buf.String())
+	}
+	if !strings.Contains(entries[0], `panic: boom\n\ngoroutine `) {
+		t.Errorf("stack not escaped in %q", entries[0])
+	}
+	if got, want := entries[1], "[ERROR]   multi\\r\\nline k=\"v\\nw\"\n"; got != want {
+		t.Errorf("second entry = %q, want %q", got, want)
+	}
+
+	// Lquote takes precedence.
+	buf.Reset()
+	l.Outdent()
+	l.SetFlags(Lquote | Lsingleline)
+	l.Errorln("a \"b\"\nc")
+	if got, want := buf.String(), `"a \"b\"\nc"`+"\n"; got != want {
+		t.Errorf("quoted output = %q, want %q", got, want)
+	}
+}
+
+// infoWrapper logs s through l, like a helper function of a program.
+func infoWrapper(l *Logger, s string) {
+	l.Info(s)
+}
+
+func TestSetCallDepth(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", Lshortfile)
+	l.SetLevel(LevelInfo)
+
+	l.SetCallDepth(1)
+	_, _, line, _ := runtime.Caller(0)
+	infoWrapper(l, "wrapped")
+	if got, want := buf.String(), fmt.Sprintf("log_test.go:%d: wrapped\n", line+1); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func BenchmarkDisabledDebugf(b *testing.B) {
+	l := New(io.Discard, "", LstdFlags)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Debugf("x %d", 1)
+		}
+	})
+}
+
+func BenchmarkInfo(b *testing.B) {
+	l := New(io.Discard, "", LstdFlags)
+	l.SetLevel(LevelInfo)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("hello")
+	}
+}
+
+func BenchmarkInfoJSON(b *testing.B) {
+	l := New(io.Discard, "", LstdFlags|LJSON)
+	l.SetLevel(LevelInfo)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("hello")
+	}
+}
+
+func BenchmarkParallelInfo(b *testing.B) {
+	l := New(io.Discard, "", LstdFlags)
+	l.SetLevel(LevelInfo)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info("hello")
+		}
+	})
+}
+
+func TestColumnWidths(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", Lshortfile|Llabel)
+	l.SetColumnWidths(16, 7)
+	_, _, line, _ := runtime.Caller(0)
+	l.Error("short")
+	l.SetColumnWidths(8, 0)
+	l.Error("truncated")
+	l.SetColumnWidths(2, 0)
+	l.Error("line kept")
+
+	want := fmt.Sprintf("log_test.go:%d:  [ERROR  ] short\n", line+1) +
+		fmt.Sprintf("….go:%d: [ERROR] truncated\n", line+3) +
+		fmt.Sprintf("…:%d: [ERROR] line kept\n", line+5)
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFitColumn(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+		pad   int
+	}{
+		{"a.go:1", 8, "a.go:1", 2},
+		{"a.go:1", 6, "a.go:1", 0},
+		{"server/handler.go:412", 18, "…er/handler.go:412", 0},
+		{"dir/ü.go:7", 7, "…ü.go:7", 0},
+		{"handler.go:412", 3, "…:412", 0},
+	}
+	for _, tt := range tests {
+		got, pad := fitColumn(tt.s, tt.width, len(tt.s)-strings.LastIndexByte(tt.s, ':'))
+		if got != tt.want || pad != tt.pad {
+			t.Errorf("fitColumn(%q, %d) = %q, %d, want %q, %d", tt.s, tt.width, got, pad, tt.want, tt.pad)
+		}
+	}
+}
+
+type panicStringer struct{}
+
+func (panicStringer) String() string { panic("bad stringer") }
+
+type panicError struct{}
+
+func (panicError) Error() string { panic(errors.New("bad error")) }
+
+func TestFormatPanic(t *testing.T) {
+	var buf, in bytes.Buffer
+	l := New(&buf, "", Lverboseerr)
+	l.SetInternalOutput(&in)
+
+	l.Error("stringer ", panicStringer{})
+	l.Errorf("error %v", panicError{})
+	want := "stringer %!v(PANIC=String method: bad stringer)\n" +
+		"!PANIC(*errors.errorString: bad error)\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if got, want := in.String(), "log: recovered from panic while formatting entry: !PANIC(*errors.errorString: bad error)\n"; got != want {
+		t.Errorf("internal output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+package log
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// A Format is the format in which a Logger writes its entries, see SetFormat.
+type Format int
+
+const (
+	FormatText   Format = iota // text, formatted according to the flags
+	FormatJSON                 // JSON objects, see LJSON
+	FormatLogfmt               // logfmt key=value pairs, see Llogfmt
+)
+
+// Format returns the format in which the logger writes its entries.
+func (l *Logger) Format() Format {
+	switch flag := l.Flags(); {
+	case flag&LJSON != 0:
+		return FormatJSON
+	case flag&Llogfmt != 0:
+		return FormatLogfmt
+	}
+	return FormatText
+}
+
+// SetFormat sets the format in which the logger writes its entries, by setting
+// LJSON or Llogfmt, or neither for FormatText, in its flags.
+func (l *Logger) SetFormat(f Format) {
+	defer l.unlock(l.lock())
+	flag := l.flag &^ (LJSON | Llogfmt)
+	switch f {
+	case FormatJSON:
+		flag |= LJSON
+	case FormatLogfmt:
+		flag |= Llogfmt
+	}
+	l.flag = flag
+}
+
+func SetFormat(f Format) {
+	std.SetFormat(f)
+}
+
+// appendLogfmt appends an entry formatted for Llogfmt to b: key=value pairs on
+// a single line. It must be called directly by format or sprintEntry, or with
+// l.skip set accordingly.
+//
+// The keys are ts (if Ldate, Ltime or Lmicroseconds is set, in RFC 3339
+// format), level, level_num (see SetLevelNumbering), prefix (if not empty),
+// logger (for loggers returned by Named), caller (if Llongfile or Lshortfile
+// is set, as file:line), code (for entries logged by Errorc and the like) and
+// msg, followed by the fields of the entry as in text output. Values are quoted if they are empty or contain
+// spaces, equal signs, quotes or line breaks, which are escaped.
+func (l *Logger) appendLogfmt(b []byte, level int, msg string, fields []Field, extra entryExtra) []byte {
+	if l.flag&(Ldate|Ltime|Lmicroseconds) != 0 {
+		t := l.now()
+		if l.flag&LUTC != 0 {
+			t = t.UTC()
+		}
+		layout := "2006-01-02T15:04:05Z07:00"
+		if l.flag&Lmicroseconds != 0 {
+			layout = "2006-01-02T15:04:05.000000Z07:00"
+		}
+		b = appendLogfmtPair(b, "ts", t.Format(layout))
+	}
+	b = appendLogfmtPair(b, "level", strings.ToLower(levelName(level)))
+	if l.lnum != NumberingNone {
+		b = appendLogfmtPair(b, "level_num", strconv.Itoa(levelNumber(l.lnum, level)))
+	}
+	if prefix := l.Prefix(); prefix != "" {
+		b = appendLogfmtPair(b, "prefix", strings.TrimSpace(prefix))
+	}
+	if l.comp != nil {
+		b = appendLogfmtPair(b, "logger", l.comp.name)
+	}
+	if l.flag&(Lshortfile|Llongfile) != 0 {
+		// Skip appendLogfmt, format and the logging method.
+		_, file, line, ok := runtime.Caller(3 + l.skip)
+		if !ok {
+			file, line = "???", 0
+		}
+		if l.flag&Lshortfile != 0 {
+			file = filepath.Base(file)
+		}
+		b = appendLogfmtPair(b, "caller", file+":"+strconv.Itoa(line))
+	}
+	if extra.code != "" {
+		b = appendLogfmtPair(b, "code", extra.code)
+	}
+	b = appendLogfmtPair(b, "msg", strings.TrimSuffix(msg, "\n"))
+	b = appendFieldList(b, fields, l)
+	if l.eol != "" {
+		return append(b, l.eol...)
+	}
+	return append(b, '\n')
+}
+
+// appendLogfmtPair appends key=value to b, preceded by a space unless b is
+// empty, and quotes the value like appendField.
+func appendLogfmtPair(b []byte, key, value string) []byte {
+	if len(b) > 0 {
+		b = append(b, ' ')
+	}
+	b = append(b, key...)
+	b = append(b, '=')
+	if value == "" || strings.ContainsAny(value, " =\"\t\r\n") {
+		return strconv.AppendQuote(b, value)
+	}
+	return append(b, value...)
+}
@@ -0,0 +1,65 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestLogfmt(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "svc ", LUTC|Ltime|Lshortfile)
+	l.now = func() time.Time { return time.Unix(0, 0) }
+	l.SetFormat(FormatLogfmt)
+	if f := l.Format(); f != FormatLogfmt {
+		t.Fatalf("Format() = %d, want FormatLogfmt", f)
+	}
+
+	l.Errorw("say \"hi\"\nbye", "k", "a b", "n", 3, "empty", "")
+	want := `ts=1970-01-01T00:00:00Z level=error prefix=svc caller=logfmt_test.go:18 msg="say \"hi\"\nbye" k="a b" n=3 empty=""` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestSetFormat(t *testing.T) {
+	l := New(&bytes.Buffer{}, "", Ltime|LJSON)
+	l.SetFormat(FormatLogfmt)
+	if got, want := FlagsString(l.Flags()), "time,logfmt"; got != want {
+		t.Errorf("flags after FormatLogfmt = %s, want %s", got, want)
+	}
+	l.SetFormat(FormatText)
+	if got, want := FlagsString(l.Flags()), "time"; got != want {
+		t.Errorf("flags after FormatText = %s, want %s", got, want)
+	}
+}
+
+func TestLevelNumbering(t *testing.T) {
+	saved := levels.Load()
+	t.Cleanup(func() { levels.Store(saved) })
+	const levelTrace = LevelDebug + 1
+	RegisterLevel(levelTrace, "TRACE", 0)
+
+	tests := []struct {
+		n      LevelNumbering
+		format Format
+		want   string
+	}{
+		{NumberingNone, FormatLogfmt, "level=warn msg=w\nlevel=trace msg=t\n"},
+		{NumberingLevel, FormatLogfmt, "level=warn level_num=3 msg=w\nlevel=trace level_num=6 msg=t\n"},
+		{NumberingSyslog, FormatLogfmt, "level=warn level_num=4 msg=w\nlevel=trace level_num=7 msg=t\n"},
+		{NumberingLevel, FormatJSON, `{"level":"warn","level_num":3,"msg":"w"}` + "\n" + `{"level":"trace","level_num":6,"msg":"t"}` + "\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		l := New(&buf, "", 0)
+		l.SetLevel(levelTrace)
+		l.SetFormat(tt.format)
+		l.SetLevelNumbering(tt.n)
+		l.Warn("w")
+		l.Log(levelTrace, "t")
+		if got := buf.String(); got != tt.want {
+			t.Errorf("numbering %d, format %d: got %q, want %q", tt.n, tt.format, got, tt.want)
+		}
+	}
+}
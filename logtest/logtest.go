@@ -0,0 +1,102 @@
+// Package logtest helps testing code that logs through the package-level
+// functions of package log.
+package logtest
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/semrekkers/log"
+)
+
+// active is set while a test captures the standard logger.
+var active atomic.Bool
+
+// A Recorder records the entries logged through the standard logger during a
+// test. It can be used simultaneously from multiple goroutines.
+type Recorder struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	code  int
+	fatal bool
+}
+
+// Capture replaces the standard logger by a Logger that writes to the returned
+// Recorder, with debug level and labels enabled, and restores the previous one
+// when the test and its subtests have completed. While capturing, the Fatal
+// functions record their exit code instead of exiting, and return.
+//
+// Since the standard logger is shared, only one test can capture it at a time;
+// Capture fails the test if another one is capturing it, as happens when such
+// tests run in parallel.
+func Capture(t testing.TB) *Recorder {
+	t.Helper()
+	if !active.CompareAndSwap(false, true) {
+		t.Fatal("logtest: standard logger is already captured by another test")
+	}
+	r := new(Recorder)
+	l := log.New(r, "", log.Llabel)
+	l.SetLevel(log.LevelDebug)
+	prev := log.StdLogger()
+	log.SetStdLogger(l)
+	prevExit := log.SetExitFunc(r.exit)
+	t.Cleanup(func() {
+		log.SetExitFunc(prevExit)
+		log.SetStdLogger(prev)
+		active.Store(false)
+	})
+	return r
+}
+
+// Write implements io.Writer.
+func (r *Recorder) Write(p []byte) (n int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.Write(p)
+}
+
+func (r *Recorder) exit(code int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.code, r.fatal = code, true
+}
+
+// String returns the recorded output.
+func (r *Recorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.String()
+}
+
+// Lines returns the recorded output split into lines, without line endings.
+func (r *Recorder) Lines() []string {
+	s := strings.TrimSuffix(r.String(), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// Contains reports whether the recorded output contains s.
+func (r *Recorder) Contains(s string) bool {
+	return strings.Contains(r.String(), s)
+}
+
+// Exited reports whether a Fatal function was called, and the exit code it
+// would have exited with.
+func (r *Recorder) Exited() (code int, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.code, r.fatal
+}
+
+// Reset discards the recorded output and exit code.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf.Reset()
+	r.code, r.fatal = 0, false
+}
@@ -0,0 +1,54 @@
+package log
+
+import (
+	"runtime"
+	"time"
+)
+
+// MemStatsFields returns a summary of the memory statistics of the program as
+// fields: the bytes of allocated and in-use heap, the number of completed GC
+// cycles, the total GC pause time and the number of goroutines. It calls
+// runtime.ReadMemStats, which briefly stops the world.
+func MemStatsFields() []Field {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return []Field{
+		Bytes("heap_alloc", int64(m.HeapAlloc)),
+		Bytes("heap_inuse", int64(m.HeapInuse)),
+		{"num_gc", m.NumGC},
+		Dur("gc_pause", time.Duration(m.PauseTotalNs)),
+		{"goroutines", runtime.NumGoroutine()},
+	}
+}
+
+// LogMemStats logs the fields returned by MemStatsFields at the given level.
+// With human readable output, sizes and durations are shown like 12.5MiB and
+// 3.2ms. The statistics are only read when LogMemStats is called and the level
+// is enabled; to log them periodically, call it from a ticker:
+//
+//	go func() {
+//		for range time.Tick(time.Minute) {
+//			logger.LogMemStats(log.LevelInfo)
+//		}
+//	}()
+func (l *Logger) LogMemStats(level int) {
+	if l.suppressed(level) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(level) {
+		f := MemStatsFields()
+		l.format(level, string(appendFieldList([]byte("memory:"), f, l)))
+	}
+}
+
+func LogMemStats(level int) {
+	if std.suppressed(level) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(level) {
+		f := MemStatsFields()
+		std.format(level, string(appendFieldList([]byte("memory:"), f, std)))
+	}
+}
@@ -0,0 +1,178 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// A component is a name given to loggers with Named, with its log level.
+type component struct {
+	name   string
+	parent *component
+	level  atomic.Int64 // -1 if not set
+}
+
+// A registry holds the components of the loggers derived from a Logger
+// created by New, by name. Clones share the registry of the original.
+type registry struct {
+	mu    sync.Mutex
+	comps map[string]*component
+}
+
+// lookup returns the component with the given dotted name, creating it and its
+// parents if needed.
+func (r *registry) lookup(name string) *component {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.comps == nil {
+		r.comps = make(map[string]*component)
+	}
+	var c *component
+	for i := 0; i <= len(name); i++ {
+		if i < len(name) && name[i] != '.' {
+			continue
+		}
+		parent := c
+		if c = r.comps[name[:i]]; c == nil {
+			c = &component{name: name[:i], parent: parent}
+			c.level.Store(-1)
+			r.comps[name[:i]] = c
+		}
+	}
+	return c
+}
+
+// find returns the component with the given name, or nil if there is none.
+func (r *registry) find(name string) *component {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.comps[name]
+}
+
+// threshold returns the level of c, that of its closest parent that has one,
+// or def.
+func (c *component) threshold(def int) int {
+	for ; c != nil; c = c.parent {
+		if level := c.level.Load(); level >= 0 {
+			return int(level)
+		}
+	}
+	return def
+}
+
+// Named returns a Logger that writes through l for the component with the
+// given name, like "db". Its entries are marked with the name, as a "db: "
+// segment before the message, or a "logger" key with LJSON, and in the Name of
+// the Entry passed to hooks and error reporters. Calling Named on the returned
+// Logger gives a dotted name, like "db.pool".
+//
+// Its log level is the one set for the name with SetLevelFor or SetLevels,
+// otherwise that of its closest parent name, otherwise the level of l. Levels
+// can be set for a name both before and after loggers with that name are
+// created. SetLevel on the returned Logger sets the level for its name. Names
+// and their levels belong to the Logger created by New that l derives from,
+// so that two such loggers can give the same name different levels.
+func (l *Logger) Named(name string) *Logger {
+	if l.comp != nil {
+		name = l.comp.name + "." + name
+	}
+	d := &Logger{
+		logger: l.logger,
+		every:  l.every,
+		with:   l.with,
+		comp:   l.reg.lookup(name),
+	}
+	d.depth.Store(l.depth.Load())
+	return d
+}
+
+func Named(name string) *Logger {
+	return std.Named(name)
+}
+
+// SetLevelFor sets the log level of the loggers derived from l with the given
+// full name, and of those with names below it that have no level of their own,
+// see Named. A negative level removes the level set for the name, so that it
+// follows its parent again. It panics if level is not registered.
+func (l *Logger) SetLevelFor(name string, level int) {
+	if level >= 0 && !validLevel(level) {
+		panic("invalid log level")
+	}
+	if level < 0 {
+		if c := l.reg.find(name); c != nil {
+			c.level.Store(-1)
+		}
+		return
+	}
+	l.reg.lookup(name).level.Store(int64(level))
+}
+
+// LevelFor returns the level set for the given full name, or -1 if there is
+// none.
+func (l *Logger) LevelFor(name string) int {
+	if c := l.reg.find(name); c != nil {
+		return int(c.level.Load())
+	}
+	return -1
+}
+
+func SetLevelFor(name string, level int) {
+	std.SetLevelFor(name, level)
+}
+
+func LevelFor(name string) int {
+	return std.LevelFor(name)
+}
+
+func SetLevels(spec string) error {
+	return std.SetLevels(spec)
+}
+
+// SetLevels sets the levels of the loggers derived from l by name from a
+// comma-separated list of name=level pairs, as accepted by ParseLevel, like
+// "db=debug,http=warn". No levels are set if the list is invalid.
+func (l *Logger) SetLevels(spec string) error {
+	type setting struct {
+		name  string
+		level int
+	}
+	var settings []setting
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return fmt.Errorf("log: invalid level setting %q", pair)
+		}
+		level, err := ParseLevel(value)
+		if err != nil {
+			return err
+		}
+		settings = append(settings, setting{name, level})
+	}
+	for _, s := range settings {
+		l.SetLevelFor(s.name, s.level)
+	}
+	return nil
+}
+
+// threshold returns the log level of l. It does not need l.mu to be held.
+func (l *Logger) threshold() int {
+	if l.comp != nil {
+		return l.comp.threshold(int(l.level.Load()))
+	}
+	return int(l.level.Load())
+}
+
+// name returns the component name of l, or "".
+func (l *Logger) name() string {
+	if l.comp != nil {
+		return l.comp.name
+	}
+	return ""
+}
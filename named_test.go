@@ -0,0 +1,67 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", Llabel)
+	l.SetLevel(LevelInfo)
+	db := l.Named("db")
+	pool := db.Named("pool")
+	http := l.Named("http")
+	if err := l.SetLevels("db=debug, http=warn"); err != nil {
+		t.Fatal(err)
+	}
+	late := l.Named("db").With("k", 1)
+	db.Debug("a")
+	pool.Debug("b")
+	http.Info("hidden")
+	late.Debug("c")
+	l.Debug("hidden")
+	pool.SetLevel(LevelError)
+	pool.Warn("hidden")
+	if got, want := buf.String(), "[DEBUG] db: a\n[DEBUG] db.pool: b\n[DEBUG] db: c k=1\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if l.SetLevels("x=nope") == nil {
+		t.Error("SetLevels accepted an invalid level")
+	}
+	if l.LevelFor("db.pool") != LevelError || db.Level() != LevelDebug {
+		t.Errorf("LevelFor(db.pool) = %d, db.Level() = %d", l.LevelFor("db.pool"), db.Level())
+	}
+
+	l.SetLevelFor("db", -1)
+	if db.Level() != LevelInfo {
+		t.Errorf("db.Level() = %d after removing its level, want that of l", db.Level())
+	}
+}
+
+func TestNamedRoots(t *testing.T) {
+	a := New(&bytes.Buffer{}, "", 0)
+	b := New(&bytes.Buffer{}, "", 0)
+	a.SetLevelFor("db", LevelDebug)
+	b.SetLevelFor("db", LevelWarn)
+	if got := a.Named("db").Level(); got != LevelDebug {
+		t.Errorf("level of db for a = %d, want LevelDebug", got)
+	}
+	if got := b.Named("db").Level(); got != LevelWarn {
+		t.Errorf("level of db for b = %d, want LevelWarn", got)
+	}
+	if got := a.Clone().Named("db").Level(); got != LevelDebug {
+		t.Errorf("level of db for a clone of a = %d, want LevelDebug", got)
+	}
+
+	// Looking up names that have no level does not register them.
+	for i := 0; i < 10; i++ {
+		if a.LevelFor("missing") != -1 {
+			t.Fatal("LevelFor returned a level for an unknown name")
+		}
+		a.SetLevelFor("missing.child", -1)
+	}
+	if _, ok := a.reg.comps["missing"]; ok {
+		t.Error("LevelFor registered the name it looked up")
+	}
+}
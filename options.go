@@ -0,0 +1,202 @@
+package log
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// An Option configures a Logger created by NewWithOptions.
+type Option func(*options)
+
+type options struct {
+	out    io.Writer
+	prefix string
+	flag   int
+	level  int
+	color  *bool
+	ccol   int
+	lcol   int
+}
+
+// WithOutput sets the output of the logger. The default is os.Stderr.
+func WithOutput(w io.Writer) Option {
+	return func(o *options) { o.out = w }
+}
+
+// WithPrefix sets the prefix of the logger. The default is no prefix.
+func WithPrefix(prefix string) Option {
+	return func(o *options) { o.prefix = prefix }
+}
+
+// WithFlags sets the flags of the logger. The default is LstdFlags.
+func WithFlags(flag int) Option {
+	return func(o *options) { o.flag = flag }
+}
+
+// WithLevel sets the log level of the logger. The default is LevelDefault.
+func WithLevel(level int) Option {
+	return func(o *options) { o.level = level }
+}
+
+// WithColor sets or clears Lcolor in the flags of the logger, regardless of
+// the order of the options.
+func WithColor(enable bool) Option {
+	return func(o *options) { o.color = &enable }
+}
+
+// WithColumnWidths sets the widths of the caller and label columns of the
+// header, see SetColumnWidths. The default is no alignment.
+func WithColumnWidths(caller, label int) Option {
+	return func(o *options) { o.ccol, o.lcol = caller, label }
+}
+
+// WithDevelopment configures the logger for reading entries in a terminal
+// during development: it logs debug entries, with the time, the label and the
+// caller aligned in a column of 24 characters, in color if the output is a
+// terminal. Options that follow it override these settings.
+func WithDevelopment() Option {
+	return func(o *options) {
+		o.flag = Ltime | Lshortfile | Llabel | LcolorAuto
+		o.level = LevelDebug
+		o.ccol = 24
+	}
+}
+
+// NewWithOptions returns a new Logger configured by opts. Without options, it
+// is configured like the standard logger. It panics if the level given with
+// WithLevel is not registered.
+func NewWithOptions(opts ...Option) *Logger {
+	o := options{
+		out:   os.Stderr,
+		flag:  LstdFlags,
+		level: LevelDefault,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.color != nil {
+		if *o.color {
+			o.flag |= Lcolor
+		} else {
+			o.flag &^= Lcolor
+		}
+	}
+	l := New(o.out, o.prefix, o.flag)
+	l.SetLevel(o.level)
+	l.SetColumnWidths(o.ccol, o.lcol)
+	return l
+}
+
+// Clone returns an independent copy of l, which writes to the same output but
+// has its own flags, level, prefix and other settings, so that changing them
+// does not affect l. The fields, additional outputs, hooks, error reporter and
+// exit handlers of l are copied, and the levels of named loggers remain shared
+// by name. Hooks removed from l are still called for the copy; asynchronous
+// hooks share the goroutine and queue of l. Counters, like those reported by
+// Stats and Health, start at zero. The output is not locked across clones, so
+// it must be safe for concurrent use, as for two loggers created by New with
+// the same output.
+func (l *Logger) Clone() *Logger {
+	defer l.unlock(l.lock())
+	c := &Logger{
+		with: l.with[:len(l.with):len(l.with)],
+		comp: l.comp,
+	}
+	if l.every != nil {
+		c.every = &throttle{
+			d:     l.every.d,
+			sites: make(map[uintptr]*site),
+			lines: make(map[fileLine]*site),
+		}
+	}
+	cw := &countWriter{w: l.cw.w}
+	c.logger = &logger{
+		out:    l.out,
+		isTerm: l.isTerm,
+		flag:   l.flag,
+		rtrace: l.rtrace,
+		slog:   l.slog,
+		reg:    l.reg,
+		cw:     cw,
+		now:    l.now,
+		eol:    l.eol,
+		utf8:   l.utf8,
+		indent: l.indent,
+		clink:  l.clink,
+		ccol:   l.ccol,
+		lcol:   l.lcol,
+		lnum:   l.lnum,
+		jfmt:   l.jfmt,
+		redraw: l.redraw,
+		global: l.global[:len(l.global):len(l.global)],
+		dyn:    l.dyn[:len(l.dyn):len(l.dyn)],
+		human:  l.human,
+		rep:    l.rep,
+		slow:   l.slow,
+		wrap:   l.wrap,
+		sumx:   l.sumx,
+		mcolor: l.mcolor,
+		autoc:  l.autoc,
+		cdepth: l.cdepth,
+		exitfn: l.exitfn[:len(l.exitfn):len(l.exitfn)],
+	}
+	c.depth.Store(l.depth.Load())
+	c.prefix.Store(l.prefix.Load())
+	c.level.Store(l.level.Load())
+	c.plevel.Store(l.plevel.Load())
+	l.diag.mu.Lock()
+	c.diag.w = l.diag.w
+	l.diag.mu.Unlock()
+	if l.burst != nil {
+		c.burst = &burst{
+			n:      l.burst.n,
+			window: l.burst.window,
+			fn:     l.burst.fn,
+			times:  make([]time.Time, l.burst.n),
+		}
+	}
+	if l.retro != nil {
+		c.retro = &retro{msgs: make([]string, len(l.retro.msgs))}
+		c.rdebug.Store(true)
+	}
+	if l.recent != nil {
+		c.recent = &retro{msgs: make([]string, len(l.recent.msgs))}
+	}
+	for level, rw := range l.route {
+		if rw != nil {
+			c.route[level] = &countWriter{w: rw.w}
+		}
+	}
+	for _, t := range l.tees {
+		c.tees = append(c.tees, &tee{
+			w:      t.w,
+			flag:   t.flag,
+			isTerm: t.isTerm,
+			autoc:  t.autoc,
+		})
+	}
+	c.teeerr = l.teeerr
+	c.updateOutputs()
+	// An asynchronous hook is shared with c, so that removing it from l only
+	// closes its queue once c no longer uses it either.
+	for _, hk := range l.hooks {
+		if hk.queue != nil {
+			hk.refs.Add(1)
+		}
+		c.hooks = append(c.hooks, hk)
+	}
+	for level, sm := range l.sample {
+		if sm != nil {
+			c.sample[level] = &sampling{s: sm.s}
+		}
+	}
+	return c
+}
+
+// CloneWithPrefix is like Clone, but sets the prefix of the copy.
+func (l *Logger) CloneWithPrefix(prefix string) *Logger {
+	c := l.Clone()
+	c.SetPrefix(prefix)
+	return c
+}
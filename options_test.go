@@ -0,0 +1,133 @@
+package log
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	l := NewWithOptions()
+	if l.Flags() != LstdFlags || l.Level() != LevelDefault || l.Prefix() != "" {
+		t.Errorf("defaults: flags = %d, level = %d, prefix = %q", l.Flags(), l.Level(), l.Prefix())
+	}
+
+	// WithColor applies regardless of the order of the options.
+	l = NewWithOptions(WithColor(false), WithFlags(Ltime|Lcolor), WithPrefix("p "))
+	if l.Flags() != Ltime || l.Prefix() != "p " {
+		t.Errorf("flags = %d, prefix = %q", l.Flags(), l.Prefix())
+	}
+
+	l = NewWithOptions(WithDevelopment(), WithLevel(LevelInfo))
+	if caller, _ := l.ColumnWidths(); l.Flags()&Lshortfile == 0 || l.Level() != LevelInfo || caller != 24 {
+		t.Errorf("development: flags = %d, level = %d, caller width = %d", l.Flags(), l.Level(), caller)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("NewWithOptions did not panic for an unregistered level")
+		}
+	}()
+	NewWithOptions(WithLevel(MaxLevel + 1))
+}
+
+func TestClone(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewWithOptions(WithOutput(&buf), WithColor(true), WithFlags(Llabel), WithLevel(LevelInfo), WithPrefix("a "))
+	if l.Flags() != Llabel|Lcolor || l.Level() != LevelInfo {
+		t.Fatalf("flags = %d, level = %d", l.Flags(), l.Level())
+	}
+	c := l.With("k", 1).CloneWithPrefix("b ")
+	c.SetFlags(0)
+	c.SetLevel(LevelDebug)
+
+	c.Debug("x")
+	l.Debug("hidden")
+	l.Info("y")
+	if got, want := buf.String(), "b x k=1\na [\x1b[36mINFO \x1b[0m] y\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	// Changing the clone leaves the original alone.
+	c.SetPrefix("c ")
+	if l.Prefix() != "a " || l.Flags() != Llabel|Lcolor || l.Level() != LevelInfo {
+		t.Errorf("original changed: prefix = %q, flags = %d, level = %d", l.Prefix(), l.Flags(), l.Level())
+	}
+}
+
+func TestCloneAsyncHook(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	var (
+		mu   sync.Mutex
+		msgs []string
+	)
+	remove := l.AddAsyncHook(LevelError, func(e Entry) {
+		mu.Lock()
+		msgs = append(msgs, e.Message)
+		mu.Unlock()
+	}, 10)
+	c := l.Clone()
+
+	// Removing the hook from l must not close the queue used by c.
+	remove()
+	c.Error("from clone")
+	l.Error("from original")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(msgs)
+		mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(msgs) != 1 || msgs[0] != "from clone" {
+		t.Errorf("hook called with %q, want only the entry of the clone", msgs)
+	}
+}
+
+func TestCloneAsyncHookGoroutines(t *testing.T) {
+	l := New(&bytes.Buffer{}, "", 0)
+	var calls atomic.Int32
+	remove := l.AddAsyncHook(LevelError, func(e Entry) { calls.Add(1) }, 50)
+	before := runtime.NumGoroutine()
+	clones := make([]*Logger, 50)
+	for i := range clones {
+		clones[i] = l.Clone()
+	}
+	if n := runtime.NumGoroutine() - before; n > 0 {
+		t.Errorf("50 clones started %d goroutines, want 0", n)
+	}
+
+	remove()
+	for _, c := range clones {
+		c.Error("x")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for calls.Load() < 50 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := calls.Load(); n != 50 {
+		t.Errorf("hook called %d times for the clones, want 50", n)
+	}
+
+	// Without clones, removing the hook stops its goroutine.
+	l = New(&bytes.Buffer{}, "", 0)
+	before = runtime.NumGoroutine()
+	l.AddAsyncHook(LevelError, func(e Entry) {}, 10)()
+	deadline = time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine() - before; n > 0 {
+		t.Errorf("%d goroutines left after removing the hook", n)
+	}
+}
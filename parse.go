@@ -0,0 +1,300 @@
+package log
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ansiSeq matches the ANSI color sequences written with Lcolor.
+var ansiSeq = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// callerSeq matches the file:line of the caller and its trailing separator.
+var callerSeq = regexp.MustCompile(`:(\d+): `)
+
+// ErrNoHeader is returned by ParseEntry for lines that lack the header that
+// the flags call for.
+var ErrNoHeader = errors.New("log: line has no entry header")
+
+// ParseEntry parses a line written by a Logger with the given flags back into
+// an Entry. The flags must be the ones the Logger used, as the header cannot be
+// parsed unambiguously without them; color sequences are removed regardless.
+//
+// The date and time are parsed in the local time zone, unless flags includes
+// LUTC. The Level of the entry is taken from the label if flags includes
+// Llabel, and is -1 otherwise; a code segment following the label, as written
+// by Errorc, is stored as the Code. A prefix is only recognized if it is
+// followed by a part of the header. Trailing key=value pairs are split off the
+// message into Fields, with their values as strings. Quoted messages, written
+// with Lquote, are unquoted. The delta written with Ldelta is skipped.
+func ParseEntry(line string, flags int) (Entry, error) {
+	e := Entry{Level: -1}
+	s := strings.TrimRight(line, "\r\n")
+	s = ansiSeq.ReplaceAllString(s, "")
+
+	// Without Llabel, which doubles as the standard Lmsgprefix flag, the
+	// prefix comes before the rest of the header.
+	if flags&(Ldate|Ltime|Lmicroseconds) != 0 {
+		i := headerStart(s, flags)
+		if i < 0 {
+			return e, ErrNoHeader
+		}
+		if flags&Llabel == 0 {
+			e.Prefix = s[:i]
+		}
+		s = s[i:]
+
+		var err error
+		if e.Time, s, err = parseTime(s, flags); err != nil {
+			return e, err
+		}
+	}
+	if flags&(Lshortfile|Llongfile) != 0 {
+		m := callerSeq.FindStringSubmatchIndex(s)
+		if m == nil {
+			return e, ErrNoHeader
+		}
+		file := s[:m[0]]
+		if flags&Llabel == 0 && flags&(Ldate|Ltime|Lmicroseconds) == 0 && flags&Lshortfile != 0 {
+			// The prefix precedes the file name, which has no slashes.
+			if i := strings.LastIndexAny(file, " /"); i >= 0 {
+				e.Prefix, file = file[:i+1], file[i+1:]
+			}
+		}
+		e.File = file
+		e.Line, _ = strconv.Atoi(s[m[2]:m[3]])
+		s = s[m[1]:]
+	}
+	if flags&Ldelta != 0 {
+		// The delta precedes the label, after the prefix if Llabel is set.
+		i := strings.Index(s, "(+")
+		if i < 0 {
+			return e, ErrNoHeader
+		}
+		j := strings.Index(s[i:], ") ")
+		if j < 0 {
+			return e, ErrNoHeader
+		}
+		s = s[:i] + s[i+j+2:]
+	}
+	if flags&Llabel != 0 {
+		i := strings.Index(s, "[")
+		if i < 0 {
+			return e, ErrNoHeader
+		}
+		j := strings.Index(s[i:], "] ")
+		if j < 0 {
+			return e, ErrNoHeader
+		}
+		level := labelLevel(s[i+1 : i+j])
+		if level < 0 {
+			return e, ErrNoHeader
+		}
+		e.Prefix, e.Level = s[:i], level
+		s = s[i+j+2:]
+		if strings.HasPrefix(s, "[") {
+			if j := strings.Index(s, "] "); j > 1 && !strings.ContainsAny(s[1:j], " []") {
+				e.Code, s = s[1:j], s[j+2:]
+			}
+		}
+	}
+
+	if flags&Lquote != 0 {
+		if msg, fields, err := splitQuoted(s); err == nil {
+			e.Message, e.Fields = msg, fields
+			return e, nil
+		}
+	}
+	e.Message, e.Fields = splitFields(s)
+	return e, nil
+}
+
+// headerStart returns the index in s at which the date or time begins, or -1.
+func headerStart(s string, flags int) int {
+	layout := headerLayout(flags)
+	for i := 0; i+len(layout) <= len(s); i++ {
+		if _, err := time.Parse(layout, s[i:i+len(layout)]); err == nil {
+			return i
+		}
+	}
+	return -1
+}
+
+// headerLayout returns the time layout of the date and time in the header.
+func headerLayout(flags int) string {
+	var layout string
+	if flags&Ldate != 0 {
+		layout = "2006/01/02"
+	}
+	if flags&(Ltime|Lmicroseconds) != 0 {
+		if layout != "" {
+			layout += " "
+		}
+		layout += "15:04:05"
+		if flags&Lmicroseconds != 0 {
+			layout += ".000000"
+		}
+	}
+	return layout
+}
+
+// parseTime parses the date and time at the start of s, and returns the rest.
+func parseTime(s string, flags int) (time.Time, string, error) {
+	layout := headerLayout(flags)
+	if len(s) < len(layout)+1 {
+		return time.Time{}, s, ErrNoHeader
+	}
+	loc := time.Local
+	if flags&LUTC != 0 {
+		loc = time.UTC
+	}
+	t, err := time.ParseInLocation(layout, s[:len(layout)], loc)
+	if err != nil {
+		return time.Time{}, s, fmt.Errorf("log: parsing entry time: %w", err)
+	}
+	return t, s[len(layout)+1:], nil
+}
+
+// splitFields splits the trailing key=value pairs off s.
+func splitFields(s string) (string, []Field) {
+	var fields []Field
+	for {
+		i, field, ok := lastField(s)
+		if !ok {
+			break
+		}
+		fields = append(fields, field)
+		s = s[:i]
+	}
+	// The fields were found last to first.
+	for i, j := 0, len(fields)-1; i < j; i, j = i+1, j-1 {
+		fields[i], fields[j] = fields[j], fields[i]
+	}
+	return s, fields
+}
+
+// lastField parses the key=value pair at the end of s, preceded by a space,
+// and returns the index of that space.
+func lastField(s string) (int, Field, bool) {
+	var value string
+	end := len(s)
+	if strings.HasSuffix(s, `"`) {
+		// Find the opening quote of the quoted value.
+		for i := strings.LastIndex(s[:end-1], `="`); i >= 0; i = strings.LastIndex(s[:i], `="`) {
+			if v, err := strconv.Unquote(s[i+1:]); err == nil {
+				value, end = v, i
+				break
+			}
+		}
+		if end == len(s) {
+			return 0, Field{}, false
+		}
+	} else {
+		i := strings.LastIndexAny(s, " =")
+		if i < 0 || s[i] != '=' {
+			return 0, Field{}, false
+		}
+		value, end = s[i+1:], i
+	}
+	sp := strings.LastIndexByte(s[:end], ' ')
+	if sp < 0 {
+		return 0, Field{}, false
+	}
+	key := s[sp+1 : end]
+	if key == "" || strings.ContainsAny(key, "=\"") {
+		return 0, Field{}, false
+	}
+	return sp, Field{key, value}, true
+}
+
+// splitQuoted unquotes a message written with Lquote and splits off the fields
+// that follow it.
+func splitQuoted(s string) (string, []Field, error) {
+	q, err := strconv.QuotedPrefix(s)
+	if err != nil {
+		return "", nil, err
+	}
+	msg, err := strconv.Unquote(q)
+	if err != nil {
+		return "", nil, err
+	}
+	msg, fields := splitFields(msg)
+	return msg, fields, nil
+}
+
+// An EntryScanner reads entries written by a Logger. Successive calls to Scan
+// step through the entries; lines that do not start with an entry header, like
+// those of a stack trace, are added to the message of the preceding entry.
+type EntryScanner struct {
+	s     *bufio.Scanner
+	flags int
+	next  *Entry
+	entry Entry
+	err   error
+}
+
+// NewEntryScanner returns an EntryScanner that reads from r. The flags must be
+// the ones used by the Logger that wrote the entries, as for ParseEntry.
+func NewEntryScanner(r io.Reader, flags int) *EntryScanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	return &EntryScanner{s: s, flags: flags}
+}
+
+// Scan advances to the next entry, which is then available through Entry. It
+// returns false at the end of the input or when an error occurs.
+func (s *EntryScanner) Scan() bool {
+	cur := s.next
+	s.next = nil
+	for s.s.Scan() {
+		line := s.s.Text()
+		e, err := ParseEntry(line, s.flags)
+		if err != nil {
+			if cur == nil {
+				if !errors.Is(err, ErrNoHeader) {
+					s.err = err
+					return false
+				}
+				// Output that precedes the first entry.
+				continue
+			}
+			cur.Message += "\n" + strings.TrimRight(ansiSeq.ReplaceAllString(line, ""), "\r")
+			continue
+		}
+		if cur != nil && s.flags&headerFlags != 0 {
+			s.next = &e
+			s.entry = *cur
+			return true
+		}
+		if s.flags&headerFlags == 0 {
+			// Without a header, every line is an entry.
+			s.entry = e
+			return true
+		}
+		cur = &e
+	}
+	if s.err = s.s.Err(); s.err != nil || cur == nil {
+		return false
+	}
+	s.entry = *cur
+	return true
+}
+
+// headerFlags are the flags that give an entry a header by which it can be
+// told apart from a continuation line.
+const headerFlags = Ldate | Ltime | Lmicroseconds | Lshortfile | Llongfile | Llabel
+
+// Entry returns the entry read by the last call to Scan.
+func (s *EntryScanner) Entry() Entry {
+	return s.entry
+}
+
+// Err returns the first error that was encountered, other than io.EOF.
+func (s *EntryScanner) Err() error {
+	return s.err
+}
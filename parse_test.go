@@ -0,0 +1,102 @@
+package log
+
+import (
+	"bytes"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEntry(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		flags  int
+		prefix string
+		level  int
+		msg    string
+		kv     []interface{}
+		want   Entry // without Time, File and Line
+	}{
+		{"label with prefix", LstdFlags | LUTC | Llabel, "app: ", LevelWarn, "disk full", []interface{}{"free", "3%"},
+			Entry{Level: LevelWarn, Prefix: "app: ", Message: "disk full", Fields: []Field{{"free", "3%"}}}},
+		{"prefix without label", Ldate | Ltime | LUTC, "app: ", LevelWarn, "disk full", nil,
+			Entry{Level: -1, Prefix: "app: ", Message: "disk full"}},
+		{"microseconds", Ldate | Lmicroseconds | LUTC | Llabel, "", LevelInfo, "started", []interface{}{"addr", ":80", "tls", false},
+			Entry{Level: LevelInfo, Message: "started", Fields: []Field{{"addr", ":80"}, {"tls", "false"}}}},
+		{"quote", Lquote | Llabel, "", LevelError, "say \"hi\"\nbye", []interface{}{"user", "bob smith"},
+			Entry{Level: LevelError, Message: "say \"hi\"\nbye", Fields: []Field{{"user", "bob smith"}}}},
+		{"delta", Ltime | LUTC | Ldelta | Llabel, "app: ", LevelDebug, "tick", nil,
+			Entry{Level: LevelDebug, Prefix: "app: ", Message: "tick"}},
+		{"shortfile without date", Lshortfile | Llabel, "app: ", LevelError, "failed", []interface{}{"err", "no such file"},
+			Entry{Level: LevelError, Prefix: "app: ", Message: "failed", Fields: []Field{{"err", "no such file"}}}},
+		{"shortfile with prefix", Lshortfile, "app: ", LevelError, "failed", nil,
+			Entry{Level: -1, Prefix: "app: ", Message: "failed"}},
+		{"no header", 0, "app: ", LevelError, "failed", nil,
+			Entry{Level: -1, Message: "app: failed"}},
+	} {
+		var buf bytes.Buffer
+		l := New(&buf, c.prefix, c.flags)
+		l.SetLevel(LevelDebug)
+
+		start := time.Now()
+		_, _, line, _ := runtime.Caller(0)
+		l.With(c.kv...).Log(c.level, c.msg)
+		end := time.Now()
+
+		e, err := ParseEntry(buf.String(), c.flags)
+		if err != nil {
+			t.Errorf("%s: ParseEntry(%q) returned %v", c.name, buf.String(), err)
+			continue
+		}
+		if c.flags&Ldate == 0 && c.flags&(Ltime|Lmicroseconds) != 0 {
+			// Without a date, only the time of day is known.
+			clock := e.Time.Format("15:04:05")
+			if clock != start.UTC().Format("15:04:05") && clock != end.UTC().Format("15:04:05") {
+				t.Errorf("%s: time %v, want the time of day of %v", c.name, e.Time, start)
+			}
+		} else if c.flags&Ldate != 0 {
+			res := time.Second
+			if c.flags&Lmicroseconds != 0 {
+				res = time.Microsecond
+			}
+			if e.Time.Before(start.Truncate(res)) || e.Time.After(end) {
+				t.Errorf("%s: time %v, want between %v and %v", c.name, e.Time, start, end)
+			}
+		}
+		if c.flags&Lshortfile != 0 {
+			if e.File != "parse_test.go" || e.Line != line+1 {
+				t.Errorf("%s: caller %s:%d, want parse_test.go:%d", c.name, e.File, e.Line, line+1)
+			}
+		}
+		e.Time, e.File, e.Line = time.Time{}, "", 0
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%s: ParseEntry(%q) = %+v, want %+v", c.name, buf.String(), e, c.want)
+		}
+	}
+}
+
+func TestEntryScanner(t *testing.T) {
+	const flags = LstdFlags | Llabel
+	var buf bytes.Buffer
+	buf.WriteString("output before the first entry\n")
+	l := New(&buf, "", flags)
+	l.SetLevel(LevelWarn)
+	l.Error("first")
+	l.Error("multi\nline\n  indented")
+	l.Warn("last")
+
+	s := NewEntryScanner(&buf, flags)
+	var got []string
+	for s.Scan() {
+		got = append(got, s.Entry().Message)
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	want := []string{"first", "multi\nline\n  indented", "last"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("messages %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,93 @@
+package log
+
+import "sync"
+
+// pooledLogger is a derived Logger kept in loggerPool, see WithPooled.
+type pooledLogger struct {
+	Logger
+	fields  []Field // backing array of Logger.with
+	release func()
+	live    bool
+	mu      sync.Mutex
+}
+
+// loggerPool holds the pooledLoggers that are not in use.
+var loggerPool sync.Pool
+
+func init() {
+	loggerPool.New = func() interface{} {
+		p := &pooledLogger{}
+		p.release = p.put
+		return p
+	}
+}
+
+// WithPooled is like With, but takes the fields as Field values and returns a
+// Logger taken from a pool, along with a function that returns it to the pool.
+// This avoids allocating a Logger for short-lived loggers, such as the one
+// created for each request by Middleware:
+//
+//	rl, release := logger.WithPooled(log.Field{"request_id", id})
+//	defer release()
+//
+// Neither the returned Logger nor the loggers derived from it, with With and
+// the like, may be used after release is called; the fields are copied from
+// the Logger by the loggers it returns, but not by those derived from it. In
+// builds with the race detector enabled, released Loggers are not reused, and
+// logging through one, or calling release twice, panics.
+func (l *Logger) WithPooled(fields ...Field) (*Logger, func()) {
+	p := loggerPool.Get().(*pooledLogger)
+	p.mu.Lock()
+	p.live = true
+	p.mu.Unlock()
+	p.fields = append(append(p.fields[:0], l.with...), fields...)
+	p.Logger.logger = l.logger
+	p.Logger.every = l.every
+	p.Logger.comp = l.comp
+	p.Logger.with = p.fields[:len(p.fields):len(p.fields)]
+	p.Logger.depth.Store(l.depth.Load())
+	return &p.Logger, p.release
+}
+
+func WithPooled(fields ...Field) (*Logger, func()) {
+	return std.WithPooled(fields...)
+}
+
+// put returns p to loggerPool.
+func (p *pooledLogger) put() {
+	p.mu.Lock()
+	live := p.live
+	p.live = false
+	p.mu.Unlock()
+	if !live {
+		if poolCheck {
+			panic("log: release of a pooled Logger called twice")
+		}
+		return
+	}
+	clear(p.fields)
+	if poolCheck {
+		p.Logger.logger = releasedLogger().logger
+		p.Logger.with = nil
+		return
+	}
+	p.Logger.logger = nil
+	p.Logger.every = nil
+	p.Logger.comp = nil
+	p.Logger.with = nil
+	loggerPool.Put(p)
+}
+
+// releasedLogger is the logger of a pooled Logger after its release, in builds
+// that check for misuse: it panics for every entry.
+var releasedLogger = sync.OnceValue(func() *Logger {
+	l := New(releasedOutput{}, "", 0)
+	l.level.Store(MaxLevel)
+	return l
+})
+
+type releasedOutput struct{}
+
+func (releasedOutput) Write(p []byte) (int, error) {
+	panic("log: pooled Logger used after its release")
+}
@@ -0,0 +1,7 @@
+//go:build !race
+
+package log
+
+// poolCheck enables the detection of pooled Loggers used after their release,
+// see WithPooled.
+const poolCheck = false
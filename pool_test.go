@@ -0,0 +1,93 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithPooled(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	w := l.With("a", 1)
+	for i := 0; i < 3; i++ {
+		pl, release := w.WithPooled(Field{"i", i})
+		pl.Error("x")
+		release()
+	}
+	pl, release := l.WithPooled()
+	pl.Error("plain")
+	release()
+	want := "x a=1 i=0\nx a=1 i=1\nx a=1 i=2\nplain\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestWithPooledMisuse(t *testing.T) {
+	if !poolCheck {
+		t.Skip("misuse is only detected with the race detector")
+	}
+	pl, release := New(io.Discard, "", 0).WithPooled(Field{"k", 1})
+	release()
+	for name, fn := range map[string]func(){
+		"log":     func() { pl.Error("after release") },
+		"release": release,
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s after release did not panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	l.SetLevel(LevelInfo)
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("handling")
+		w.WriteHeader(http.StatusTeapot)
+		io.WriteString(w, "tea")
+	}))
+	r := httptest.NewRequest("GET", "/pot", nil)
+	r.Header.Set("X-Request-Id", "r1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || lines[0] != "handling request_id=r1" {
+		t.Fatalf("output = %q", buf.String())
+	}
+	for _, s := range []string{"request request_id=r1", "request.method=GET", "request.url=/pot", "response.status=418", "response.size=3"} {
+		if !strings.Contains(lines[1], s) {
+			t.Errorf("request entry %q does not contain %q", lines[1], s)
+		}
+	}
+}
+
+func BenchmarkWith(b *testing.B) {
+	l := New(io.Discard, "", 0)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rl := l.With("request_id", "r1", "user", "u1", "tenant", "t1")
+		_ = rl
+	}
+}
+
+func BenchmarkWithPooled(b *testing.B) {
+	l := New(io.Discard, "", 0)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rl, release := l.WithPooled(Field{"request_id", "r1"}, Field{"user", "u1"}, Field{"tenant", "t1"})
+		_ = rl
+		release()
+	}
+}
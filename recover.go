@@ -0,0 +1,73 @@
+package log
+
+import (
+	"bytes"
+	"runtime/debug"
+)
+
+// Recover recovers from a panic, if one is in flight, and logs the panic value
+// and the stack of the panicking goroutine at error level, preceded by msg. It
+// must be called directly by a deferred function call:
+//
+//	go func() {
+//		defer logger.Recover("worker")
+//		...
+//	}()
+func (l *Logger) Recover(msg string) {
+	if p := recover(); p != nil {
+		l.logPanic(LevelError, msg, p)
+	}
+}
+
+// RecoverAndRepanic is like Recover, but logs at panic level and panics again
+// with the recovered value.
+func (l *Logger) RecoverAndRepanic(msg string) {
+	if p := recover(); p != nil {
+		l.logPanic(LevelPanic, msg, p)
+		panic(p)
+	}
+}
+
+func Recover(msg string) {
+	if p := recover(); p != nil {
+		std.logPanic(LevelError, msg, p)
+	}
+}
+
+func RecoverAndRepanic(msg string) {
+	if p := recover(); p != nil {
+		std.logPanic(LevelPanic, msg, p)
+		panic(p)
+	}
+}
+
+func (l *Logger) logPanic(level int, msg string, p interface{}) {
+	stack := panicStack()
+	defer l.unlock(l.lock())
+	if l.enabled(level) {
+		l.extra = entryExtra{panic: p, stack: stack}
+		l.format(level, msg+": panic: "+l.sprint(p)+"\n\n"+string(stack))
+	}
+}
+
+// panicStack returns the stack of the calling goroutine, without the frames of
+// the recovery itself: everything up to and including the call to panic.
+func panicStack() []byte {
+	stack := debug.Stack()
+	i := bytes.IndexByte(stack, '\n')
+	if i < 0 {
+		return stack
+	}
+	header, frames := stack[:i+1], stack[i+1:]
+	if j := bytes.Index(frames, []byte("\npanic(")); j >= 0 {
+		// Skip the panic call and its file:line line.
+		rest := frames[j+1:]
+		for n := 0; n < 2; n++ {
+			if k := bytes.IndexByte(rest, '\n'); k >= 0 {
+				rest = rest[k+1:]
+			}
+		}
+		frames = rest
+	}
+	return append(header, frames...)
+}
@@ -0,0 +1,76 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type jobState struct {
+	ID   int
+	Step string
+}
+
+func TestRecover(t *testing.T) {
+	for _, c := range []struct {
+		value interface{}
+		msg   string
+	}{
+		{errors.New("boom"), "[ERROR] worker: panic: boom\n"},
+		{"out of range", "[ERROR] worker: panic: out of range\n"},
+		{jobState{7, "upload"}, "[ERROR] worker: panic: {7 upload}\n"},
+	} {
+		var buf bytes.Buffer
+		l := New(&buf, "", Llabel)
+		var entry Entry
+		l.AddHook(LevelError, func(e Entry) { entry = e })
+
+		func() {
+			defer l.Recover("worker")
+			panic(c.value)
+		}()
+		out := buf.String()
+		if !strings.HasPrefix(out, c.msg+"\ngoroutine ") {
+			t.Errorf("%v: output %q, want it to start with %q and the stack", c.value, out, c.msg)
+		}
+		// The stack starts at the function that panicked.
+		stack := out[strings.Index(out, "\ngoroutine "):]
+		if strings.Contains(stack, "panicStack") || strings.Contains(stack, "panic(") ||
+			!strings.Contains(stack, "log.TestRecover.func") {
+			t.Errorf("%v: stack not trimmed to the panicking function:\n%s", c.value, stack)
+		}
+		if !reflect.DeepEqual(entry.Panic, c.value) || len(entry.Stack) == 0 {
+			t.Errorf("%v: entry with panic %v and %d bytes of stack", c.value, entry.Panic, len(entry.Stack))
+		}
+	}
+
+	// Without a panic, nothing happens.
+	var buf bytes.Buffer
+	l := New(&buf, "", Llabel)
+	func() {
+		defer l.Recover("worker")
+	}()
+	if buf.Len() != 0 {
+		t.Errorf("Recover without a panic wrote %q", buf.String())
+	}
+}
+
+func TestRecoverAndRepanic(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", Llabel)
+	err := errors.New("boom")
+	defer func() {
+		if p := recover(); p != err {
+			t.Errorf("repanicked with %v, want %v", p, err)
+		}
+		if out := buf.String(); !strings.HasPrefix(out, "[PANIC] worker: panic: boom\n") {
+			t.Errorf("output = %q", out)
+		}
+	}()
+	func() {
+		defer l.RecoverAndRepanic("worker")
+		panic(err)
+	}()
+}
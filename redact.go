@@ -0,0 +1,115 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// maxRedactDepth is the maximum depth to which redactString follows nested
+// structs and pointers.
+const maxRedactDepth = 10
+
+// redactTypes caches whether a type needs redaction, see needsRedaction.
+var redactTypes sync.Map // map[reflect.Type]bool
+
+// redactString renders v, a struct or pointer to a struct, while honoring the
+// log tags of its fields, as described for Field. It reports false if v has no
+// such tags, so that v can be formatted as usual.
+func redactString(v interface{}) (string, bool) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || !needsRedaction(rv.Type()) {
+		return "", false
+	}
+	return string(appendRedacted(nil, rv, 0)), true
+}
+
+// needsRedaction reports whether values of type t have struct fields with log
+// tags, directly or in nested structs.
+func needsRedaction(t reflect.Type) bool {
+	if v, ok := redactTypes.Load(t); ok {
+		return v.(bool)
+	}
+	// Recursive types are assumed to need no redaction while their fields are
+	// being inspected.
+	redactTypes.Store(t, false)
+	needs := false
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		needs = needsRedaction(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField() && !needs; i++ {
+			f := t.Field(i)
+			_, tagged := f.Tag.Lookup("log")
+			needs = tagged || needsRedaction(f.Type)
+		}
+	}
+	redactTypes.Store(t, needs)
+	return needs
+}
+
+func appendRedacted(b []byte, v reflect.Value, depth int) []byte {
+	if depth > maxRedactDepth || !needsRedaction(v.Type()) {
+		return fmt.Append(b, v)
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return append(b, "<nil>"...)
+		}
+		return appendRedacted(b, v.Elem(), depth+1)
+	case reflect.Slice, reflect.Array:
+		b = append(b, '[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b = append(b, ' ')
+			}
+			b = appendRedacted(b, v.Index(i), depth+1)
+		}
+		return append(b, ']')
+	}
+
+	b = append(b, '{')
+	first := true
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, mask, omit := parseLogTag(f)
+		if omit {
+			continue
+		}
+		if !first {
+			b = append(b, ' ')
+		}
+		first = false
+		b = append(b, name...)
+		b = append(b, ':')
+		if mask {
+			b = append(b, "***"...)
+		} else {
+			b = appendRedacted(b, v.Field(i), depth+1)
+		}
+	}
+	return append(b, '}')
+}
+
+// parseLogTag returns the options of the log tag of f.
+func parseLogTag(f reflect.StructField) (name string, mask, omit bool) {
+	name = f.Name
+	tag, ok := f.Tag.Lookup("log")
+	if !ok {
+		return
+	}
+	for _, opt := range strings.Split(tag, ",") {
+		switch {
+		case opt == "-":
+			omit = true
+		case opt == "mask":
+			mask = true
+		case strings.HasPrefix(opt, "name="):
+			name = opt[len("name="):]
+		}
+	}
+	return
+}
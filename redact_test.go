@@ -0,0 +1,44 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+)
+
+type redactMeta struct {
+	ID, Version int
+}
+
+type redactCreds struct {
+	User     string `log:"name=user"`
+	Password string `log:"-"`
+	Token    string `log:"mask"`
+}
+
+type redactAccount struct {
+	redactMeta
+	Creds   *redactCreds
+	History []redactCreds
+	Backup  *redactCreds
+	note    string
+}
+
+func TestRedact(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	creds := &redactCreds{"alice", "hunter2", "t0k3n"}
+	account := redactAccount{redactMeta{7, 2}, creds, []redactCreds{*creds}, nil, "vip"}
+
+	l.Errorw("login failed", "account", account, "creds", creds, "meta", redactMeta{1, 1})
+	l.SetFlags(LJSON)
+	l.Errorw("login failed", "creds", *creds)
+	want := `login failed account="{redactMeta:{7 2} Creds:{user:alice Token:***} History:[{user:alice Token:***}] Backup:<nil> note:vip}"` +
+		` creds="{user:alice Token:***}" meta="{1 1}"` + "\n" +
+		`{"level":"error","msg":"login failed","creds":"{user:alice Token:***}"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %s\nwant     %s", got, want)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("hunter2")) || bytes.Contains(buf.Bytes(), []byte("t0k3n")) {
+		t.Error("output contains a secret")
+	}
+}
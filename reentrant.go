@@ -0,0 +1,110 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// lock locks l.mu and reports whether it did; the result must be passed to
+// unlock. While an entry is passed to code that may log, like the output or a
+// hook, l.owner holds the goroutine writing it; see enter. If that goroutine
+// calls back into l, lock does not wait for l.mu, which it already holds. The
+// entry is then suppressed by format and reported on the internal output
+// instead.
+func (l *Logger) lock() (locked bool) {
+	if !l.mu.TryLock() {
+		if id := l.owner.Load(); id != 0 && id == goid() {
+			return false
+		}
+		l.mu.Lock()
+	}
+	// Details of an entry that was not logged must not end up in the next.
+	l.extra = entryExtra{}
+	l.skip = l.cdepth
+	return true
+}
+
+// unlock unlocks l.mu if locked is true.
+func (l *Logger) unlock(locked bool) {
+	if locked {
+		l.mu.Unlock()
+	}
+}
+
+// enter records the calling goroutine in l.owner if calls is true, and reports
+// whether it did; the result must be passed to leave. Looking up the goroutine
+// is costly, so it is only done when an entry is passed to code that may log.
+func (l *Logger) enter(calls bool) (entered bool) {
+	if !calls {
+		return false
+	}
+	l.owner.Store(goid())
+	return true
+}
+
+// leave clears l.owner if entered is true.
+func (l *Logger) leave(entered bool) {
+	if entered {
+		l.owner.Store(0)
+	}
+}
+
+// callsBack reports whether writing an entry at level with the given fields
+// may run code that logs to l: an output other than a file or buffer, a hook
+// called directly or its filter, an error reporter, a slog handler or a
+// LogValuer.
+func (l *Logger) callsBack(level int, fields []Field) bool {
+	if l.slog != nil || (l.rep != nil && level <= l.rep.min) || !inert(l.levelWriter(level).w) {
+		return true
+	}
+	for _, t := range l.tees {
+		if !inert(t.w) {
+			return true
+		}
+	}
+	for _, hk := range l.hooks {
+		if (hk.queue == nil || hk.filter != nil) && hk.wants(level) {
+			return true
+		}
+	}
+	for _, f := range fields {
+		switch f.Value.(type) {
+		case LogValuer, slog.LogValuer:
+			return true
+		}
+	}
+	return false
+}
+
+// inert reports whether writing to w cannot call back into a logger.
+func inert(w io.Writer) bool {
+	switch w.(type) {
+	case *os.File, *bytes.Buffer, *strings.Builder, *AsyncWriter:
+		return true
+	}
+	return w == io.Discard
+}
+
+// goid returns the id of the calling goroutine.
+func goid() int64 {
+	// The buffer escapes to runtime.Stack, so it is taken from the pool.
+	buf := getBuffer()
+	defer putBuffer(buf)
+	b := (*buf)[:64]
+	b = b[:runtime.Stack(b, false)]
+	// The stack starts with "goroutine N [".
+	b = b[len("goroutine "):]
+	for i, c := range b {
+		if c == ' ' {
+			b = b[:i]
+			break
+		}
+	}
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
@@ -0,0 +1,56 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// loggingWriter logs to l from its Write method.
+type loggingWriter struct {
+	l   *Logger
+	buf bytes.Buffer
+}
+
+func (w *loggingWriter) Write(p []byte) (int, error) {
+	w.l.Error("inner")
+	w.l.Write([]byte("raw\n"))
+	return w.buf.Write(p)
+}
+
+func TestReentrantWriter(t *testing.T) {
+	w := &loggingWriter{}
+	l := New(w, "", 0)
+	w.l = l
+	var in bytes.Buffer
+	l.SetInternalOutput(&in)
+
+	l.Error("first")
+	l.Error("second")
+	if got, want := w.buf.String(), "first\nsecond\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if n := strings.Count(in.String(), "recursive log call suppressed: inner"); n != 2 {
+		t.Errorf("internal output has %d suppressed entries, want 2:\n%s", n, in.String())
+	}
+	if !strings.Contains(in.String(), "recursive write suppressed") {
+		t.Errorf("internal output does not report the suppressed write:\n%s", in.String())
+	}
+}
+
+func TestReentrantHook(t *testing.T) {
+	var out, in bytes.Buffer
+	l := New(&out, "", 0)
+	l.SetInternalOutput(&in)
+	l.AddHook(LevelError, func(e Entry) {
+		l.Error("from hook")
+	})
+
+	l.Error("entry")
+	if got, want := out.String(), "entry\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if !strings.Contains(in.String(), "recursive log call suppressed: from hook") {
+		t.Errorf("internal output does not report the suppressed entry:\n%s", in.String())
+	}
+}
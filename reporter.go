@@ -0,0 +1,141 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reporterQueue is the number of entries that can wait to be reported.
+const reporterQueue = 256
+
+// An ErrorReporter receives entries at or above a minimum level, for example to
+// send them to an error tracking service. See (*Logger).SetErrorReporter.
+type ErrorReporter interface {
+	// Report reports an entry. It must not retain e after returning.
+	Report(e *Entry)
+
+	// Flush waits at most timeout for reported entries to be delivered.
+	Flush(timeout time.Duration)
+}
+
+// reporter passes entries to an ErrorReporter from a separate goroutine.
+type reporter struct {
+	r       ErrorReporter
+	min     int
+	queue   chan *Entry
+	pending sync.WaitGroup
+}
+
+// SetErrorReporter sets r to receive the entries logged at level min or above.
+// For example, with min LevelError, it receives error, panic and fatal
+// entries. Entries are passed to r from a separate goroutine, so a slow
+// reporter does not delay logging; when it falls behind by more than 256
+// entries, new entries are not reported. Before a fatal entry exits the
+// program, the reporter is flushed. A nil r removes the reporter.
+func (l *Logger) SetErrorReporter(r ErrorReporter, min int) {
+	defer l.unlock(l.lock())
+	if l.rep != nil {
+		close(l.rep.queue)
+		l.rep = nil
+	}
+	if r == nil {
+		return
+	}
+	rep := &reporter{
+		r:     r,
+		min:   min,
+		queue: make(chan *Entry, reporterQueue),
+	}
+	go func() {
+		for e := range rep.queue {
+			rep.r.Report(e)
+			rep.pending.Done()
+		}
+	}()
+	l.rep = rep
+}
+
+func SetErrorReporter(r ErrorReporter, min int) {
+	std.SetErrorReporter(r, min)
+}
+
+// report queues an entry for the error reporter. It must be called by format.
+func (l *Logger) report(level int, s string, fields []Field, extra entryExtra) {
+	e := &Entry{
+		Level:   level,
+		Time:    l.now(),
+		Prefix:  l.Prefix(),
+		Name:    l.name(),
+		Message: strings.TrimSuffix(s, "\n"),
+		Code:    extra.code,
+		Fields:  fields,
+		Err:     extra.err,
+		Panic:   extra.panic,
+		Stack:   extra.stack,
+	}
+	_, e.File, e.Line, _ = runtime.Caller(3 + l.skip) // skip report, format and the logging method
+	l.rep.pending.Add(1)
+	select {
+	case l.rep.queue <- e:
+	default:
+		l.rep.pending.Done()
+		l.internalf("error reporter queue is full, entry not reported")
+	}
+}
+
+// flush waits for queued entries to be reported and flushes the error
+// reporter, for at most a few seconds.
+func (rep *reporter) flush() {
+	if rep == nil {
+		return
+	}
+	const timeout = 2 * time.Second
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		rep.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+	if remaining := timeout - time.Since(start); remaining > 0 {
+		rep.r.Flush(remaining)
+	}
+}
+
+// writerReporter is the ErrorReporter returned by NewWriterReporter.
+type writerReporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterReporter returns an ErrorReporter that writes the entries it
+// receives to w, one per line followed by the stack if there is one. It is
+// useful as a secondary output for errors and as an example implementation.
+func NewWriterReporter(w io.Writer) ErrorReporter {
+	return &writerReporter{w: w}
+}
+
+func (r *writerReporter) Report(e *Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := e.Time.AppendFormat(nil, time.RFC3339)
+	b = fmt.Appendf(b, " %s %s:%d: ", levelName(e.Level), e.File, e.Line)
+	if e.Code != "" {
+		b = fmt.Appendf(b, "[%s] ", e.Code)
+	}
+	b = append(b, e.Message...)
+	b = append(b, '\n')
+	if len(e.Stack) > 0 {
+		b = append(b, e.Stack...)
+	}
+	r.w.Write(b)
+}
+
+func (r *writerReporter) Flush(timeout time.Duration) {}
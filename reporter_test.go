@@ -0,0 +1,104 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingReporter is an ErrorReporter that keeps the entries it receives.
+type recordingReporter struct {
+	mu      sync.Mutex
+	entries []Entry
+	flushed int
+}
+
+func (r *recordingReporter) Report(e *Entry) {
+	r.mu.Lock()
+	r.entries = append(r.entries, *e)
+	r.mu.Unlock()
+}
+
+func (r *recordingReporter) Flush(timeout time.Duration) {
+	r.mu.Lock()
+	r.flushed++
+	r.mu.Unlock()
+}
+
+func TestErrorReporter(t *testing.T) {
+	l := New(io.Discard, "", 0)
+	l.SetLevel(LevelDebug)
+	r := new(recordingReporter)
+	l.SetErrorReporter(r, LevelError)
+	err := errors.New("disk full")
+
+	_, _, line, _ := runtime.Caller(0)
+	l.Warn("not reported")
+	l.Wrap(err, "saving")
+	l.With("job", 7).Errorw("failed", "attempt", 2)
+	func() {
+		defer l.Recover("worker")
+		panic("boom")
+	}()
+	l.rep.flush()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.entries) != 3 {
+		t.Fatalf("%d entries reported, want 3", len(r.entries))
+	}
+	e := r.entries[0]
+	if e.Level != LevelError || e.Message != "saving: disk full" || !errors.Is(e.Err, err) ||
+		filepath.Base(e.File) != "reporter_test.go" || e.Line != line+2 {
+		t.Errorf("wrapped error reported as %+v", e)
+	}
+	e = r.entries[1]
+	if e.Message != "failed job=7 attempt=2" || fmt.Sprint(e.Fields) != "[{job 7} {attempt 2}]" || e.Line != line+3 {
+		t.Errorf("entry with fields reported as %+v", e)
+	}
+	e = r.entries[2]
+	if e.Panic != "boom" || len(e.Stack) == 0 {
+		t.Errorf("panic reported as %+v", e)
+	}
+}
+
+func TestErrorReporterFatal(t *testing.T) {
+	l := New(io.Discard, "", 0)
+	r := new(recordingReporter)
+	l.SetErrorReporter(r, LevelError)
+	// The fatal entry is reported and the reporter flushed before the exit.
+	var reported, flushed int
+	prev := SetExitFunc(func(int) {
+		r.mu.Lock()
+		reported, flushed = len(r.entries), r.flushed
+		r.mu.Unlock()
+	})
+	defer SetExitFunc(prev)
+
+	l.Fatal("bye")
+	if reported != 1 || flushed != 1 {
+		t.Errorf("%d entries reported and %d flushes before exit, want 1 and 1", reported, flushed)
+	}
+}
+
+func TestWriterReporter(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(io.Discard, "", 0)
+	l.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+	l.SetErrorReporter(NewWriterReporter(&buf), LevelError)
+
+	_, _, line, _ := runtime.Caller(0)
+	l.Errorc("E42", "lost connection")
+	l.rep.flush()
+	_, file, _, _ := runtime.Caller(0)
+	want := fmt.Sprintf("2024-05-01T12:00:00Z ERROR %s:%d: [E42] lost connection\n", file, line+1)
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
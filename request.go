@@ -0,0 +1,124 @@
+package log
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RequestOptions controls which details of a request are logged by Request.
+type RequestOptions struct {
+	// Headers lists the request headers that are logged, if present.
+	Headers []string
+
+	// RedactQuery lists the names of query parameters whose values are
+	// replaced by ***. Names are case-insensitive.
+	RedactQuery []string
+}
+
+// DefaultRequestOptions are the options used by Request. They never include
+// the Authorization and Cookie headers.
+var DefaultRequestOptions = RequestOptions{
+	Headers:     []string{"User-Agent", "Referer", "Content-Type", "X-Request-Id", "X-Forwarded-For"},
+	RedactQuery: []string{"token", "access_token", "password", "secret", "key", "api_key", "signature"},
+}
+
+// Request returns a field named request that summarizes r, using the
+// DefaultRequestOptions. It is logged as fields with a request. prefix: the
+// method, the path and query of the URL, the protocol, the remote address, the
+// host, the content length if known and the allowed headers, like
+//
+//	request.method=GET request.url="/search?q=go&token=***" request.proto=HTTP/1.1 ...
+func Request(r *http.Request) Field {
+	return DefaultRequestOptions.Request(r)
+}
+
+// Request returns a field named request that summarizes r, as described for
+// the Request function, using the options o.
+func (o RequestOptions) Request(r *http.Request) Field {
+	u := r.URL.EscapedPath()
+	if r.URL.RawQuery != "" {
+		u += "?" + o.redactQuery(r.URL.RawQuery)
+	}
+	g := group{
+		{"method", r.Method},
+		{"url", u},
+		{"proto", r.Proto},
+		{"remote", r.RemoteAddr},
+		{"host", r.Host},
+	}
+	if r.ContentLength >= 0 {
+		g = append(g, Bytes("length", r.ContentLength))
+	}
+	for _, h := range o.Headers {
+		if v := r.Header.Get(h); v != "" {
+			g = append(g, Field{strings.ToLower(h), v})
+		}
+	}
+	return Field{"request", g}
+}
+
+// redactQuery replaces the values of the parameters in o.RedactQuery in the
+// raw query q, keeping the order of the parameters.
+func (o RequestOptions) redactQuery(q string) string {
+	params := strings.Split(q, "&")
+	for i, p := range params {
+		name, _, _ := strings.Cut(p, "=")
+		if n, err := url.QueryUnescape(name); err == nil {
+			name = n
+		}
+		for _, r := range o.RedactQuery {
+			if strings.EqualFold(name, r) {
+				params[i] = p[:strings.IndexByte(p+"=", '=')] + "=***"
+				break
+			}
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// Response returns a field named response that summarizes a response, logged
+// like response.status=200 response.size=1024 response.duration=12.5.
+func Response(status int, size int64, d time.Duration) Field {
+	return Field{"response", group{
+		{"status", status},
+		Bytes("size", size),
+		Dur("duration", d),
+	}}
+}
+
+// Middleware returns an HTTP handler that logs each request handled by next,
+// at info level once it is handled, with the Request and Response fields. The
+// request ID, taken from the X-Request-Id header, is logged as request_id.
+// The handler passes next a context carrying a Logger derived from l with the
+// request ID, see FromContext, which is taken from a pool, see WithPooled, and
+// must not be used after next returns.
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rl, release := l.WithPooled(Field{"request_id", r.Header.Get("X-Request-Id")})
+		defer release()
+		rw := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r.WithContext(ContextWithLogger(r.Context(), rl)))
+		rl.Infow("request", Request(r), Response(rw.status, rw.size, time.Since(start)))
+	})
+}
+
+// responseRecorder records the status and size of a response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseRecorder) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.size += int64(n)
+	return n, err
+}
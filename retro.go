@@ -0,0 +1,91 @@
+package log
+
+// retro is a ring of entry messages. It holds the debug entries suppressed by
+// the log level for EnableRetroactiveDebug, and the recent entries for
+// KeepRecent.
+type retro struct {
+	msgs []string
+	pos  int
+	full bool
+}
+
+// EnableRetroactiveDebug makes l keep the last n debug entries that are
+// suppressed by its log level. When an entry at error level or above is logged,
+// the kept entries are logged first, at debug level and marked with [replay],
+// to show what led up to the error. They are then discarded, so that each is
+// replayed at most once. Since the original call sites are not kept, the
+// replayed entries carry the call site of the error. An n of 0 disables
+// retroactive debugging, which is the default.
+func (l *Logger) EnableRetroactiveDebug(n int) {
+	defer l.unlock(l.lock())
+	if n <= 0 {
+		l.retro = nil
+		l.rdebug.Store(false)
+		return
+	}
+	l.retro = &retro{msgs: make([]string, n)}
+	l.rdebug.Store(true)
+}
+
+func EnableRetroactiveDebug(n int) {
+	std.EnableRetroactiveDebug(n)
+}
+
+// add keeps the message of a suppressed debug entry, discarding the oldest
+// one if the buffer is full.
+func (r *retro) add(s string) {
+	r.msgs[r.pos] = s
+	r.pos++
+	if r.pos == len(r.msgs) {
+		r.pos, r.full = 0, true
+	}
+}
+
+// keepDebug keeps the message of a debug entry suppressed by the log level,
+// for retroactive debugging and, with KeepRecent, for dumps. It must be called
+// with l.mu held.
+func (l *Logger) keepDebug(s string) {
+	l.retro.add(s)
+	if l.recent != nil {
+		l.recent.add("[" + levelName(LevelDebug) + "] " + s)
+	}
+}
+
+// snapshot returns the kept messages from oldest to newest.
+func (r *retro) snapshot() []string {
+	var msgs []string
+	if r.full {
+		msgs = append(msgs, r.msgs[r.pos:]...)
+	}
+	return append(msgs, r.msgs[:r.pos]...)
+}
+
+// drain returns the kept messages from oldest to newest, and clears the buffer.
+func (r *retro) drain() []string {
+	msgs := r.snapshot()
+	clear(r.msgs)
+	r.pos, r.full = 0, false
+	return msgs
+}
+
+// replay logs the kept debug entries. It must be called by format, before the
+// entry that triggers it is written.
+func (l *Logger) replay() {
+	msgs := l.retro.drain()
+	if len(msgs) == 0 {
+		return
+	}
+	// Replay through a Logger without throttling, so that the suppressed
+	// count of the triggering entry is left alone.
+	r := &Logger{logger: l.logger}
+	r.depth.Store(l.depth.Load())
+	// The entries were kept for KeepRecent when they were suppressed.
+	recent := l.recent
+	l.recent = nil
+	l.skip += 2 // skip replay and format
+	for _, s := range msgs {
+		r.format(LevelDebug, "[replay] "+s)
+	}
+	l.skip -= 2
+	l.recent = recent
+}
@@ -0,0 +1,42 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRetroactiveDebug(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", Llabel)
+	l.SetLevel(LevelInfo)
+	l.EnableRetroactiveDebug(2)
+
+	// Only the last two suppressed debug entries are kept, and they are
+	// replayed once, before the first error of a burst.
+	l.Debug("a")
+	l.Debugf("b%d", 1)
+	l.Debugln("c")
+	l.Info("info")
+	l.Error("first error")
+	l.Error("second error")
+	l.Debug("d")
+	l.Warn("warning")
+	l.Errorw("third error", "k", "v")
+
+	l.EnableRetroactiveDebug(0)
+	l.Debug("e")
+	l.Error("fourth error")
+
+	want := "[INFO ] info\n" +
+		"[DEBUG] [replay] b1\n" +
+		"[DEBUG] [replay] c\n" +
+		"[ERROR] first error\n" +
+		"[ERROR] second error\n" +
+		"[WARN ] warning\n" +
+		"[DEBUG] [replay] d\n" +
+		"[ERROR] third error k=v\n" +
+		"[ERROR] fourth error\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,360 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// backupTimeFormat is the format of the time in the names of backups.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateOptions configures a RotatingFile. A zero value disables the
+// corresponding limit.
+type RotateOptions struct {
+	MaxSizeMB  int           // size in megabytes at which the file is rotated
+	Interval   time.Duration // age at which the file is rotated
+	MaxAgeDays int           // age in days after which backups are removed
+	MaxBackups int           // number of backups to keep
+	Compress   bool          // compress backups with gzip
+}
+
+// A RotatingFile is a file that is rotated when it reaches a maximum size, or
+// a maximum age counted from when it was opened: it is renamed to a backup
+// with the time of the rotation in its name, like
+// app-2024-01-31T15-04-05.000.log for app.log, and a new file is started. The
+// time is in UTC. Backups beyond the limits of its RotateOptions are removed,
+// and the others are compressed if requested, in the background. Errors while
+// rotating are reported on the internal output of the Logger using the file,
+// see SetInternalOutput, or on os.Stderr if it is not used by a Logger.
+//
+// A RotatingFile is rotated before a write that would make it exceed the
+// maximum size, or that comes after its maximum age, so that each write, like
+// a log entry, ends up in one file as a whole. It can be used simultaneously
+// from multiple goroutines.
+//
+// When the file is rotated or removed by another program, like logrotate,
+// call Reopen, or use ReopenOnSignal, to continue writing to a new file at
+// the same path.
+type RotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	opts    RotateOptions
+	file    *os.File // nil if a new file could not be opened, or if closed
+	closed  bool
+	size    int64
+	opened  time.Time
+	cleanup sync.Mutex
+	pending sync.WaitGroup
+	logger  atomic.Pointer[Logger]
+}
+
+// NewRotatingFile opens or creates the file at path for appending, creating
+// its directory if needed, and returns it as a RotatingFile. It can be used as
+// the output of a Logger:
+//
+//	f, err := log.NewRotatingFile("/var/log/app/app.log", log.RotateOptions{
+//		MaxSizeMB:  100,
+//		MaxBackups: 10,
+//		Compress:   true,
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer f.Close()
+//	log.SetOutput(f)
+func NewRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, opts: opts}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write writes p to the file, rotating it first if p would make it exceed the
+// maximum size, or if it has reached the maximum age. If the rotation fails,
+// this is reported like other errors while rotating, and p is written to the
+// current file if possible. If no file could be opened by a previous rotation,
+// Write tries again to open one.
+func (r *RotatingFile) Write(p []byte) (n int, err error) {
+	r.mu.Lock()
+	n, err, rerr := r.write(p)
+	r.mu.Unlock()
+	// Reported without r.mu held, in case r is the internal output.
+	if rerr != nil {
+		r.reportf("rotating %s: %v", r.path, rerr)
+	}
+	return n, err
+}
+
+// write writes p to the file, and returns the error of the rotation before
+// writing it, if any. It must be called with r.mu held.
+func (r *RotatingFile) write(p []byte) (n int, err, rerr error) {
+	if r.closed {
+		return 0, os.ErrClosed, nil
+	}
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err, nil
+		}
+	}
+	max := int64(r.opts.MaxSizeMB) << 20
+	full := max > 0 && r.size+int64(len(p)) > max
+	old := r.opts.Interval > 0 && time.Since(r.opened) >= r.opts.Interval
+	if r.size > 0 && (full || old) {
+		if rerr = r.rotate(); rerr != nil && r.file == nil {
+			return 0, rerr, rerr
+		}
+		// Otherwise the entry can still be written, as the file could not
+		// be renamed or was reopened.
+	}
+	n, err = r.file.Write(p)
+	r.size += int64(n)
+	return n, err, rerr
+}
+
+// Rotate rotates the file, regardless of its size.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return os.ErrClosed
+	}
+	if r.file == nil {
+		return r.open()
+	}
+	return r.rotate()
+}
+
+// Reopen closes the file and opens the file at its path again, creating it if
+// it no longer exists.
+func (r *RotatingFile) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return os.ErrClosed
+	}
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+	return r.open()
+}
+
+// Close closes the file, after waiting for the removal and compression of
+// backups to complete.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return os.ErrClosed
+	}
+	r.closed = true
+	var err error
+	if r.file != nil {
+		err = r.file.Close()
+		r.file = nil
+	}
+	r.pending.Wait()
+	return err
+}
+
+// reportf reports a problem of r on the internal output of the Logger using
+// it, or on os.Stderr.
+func (r *RotatingFile) reportf(format string, v ...interface{}) {
+	if l := r.logger.Load(); l != nil {
+		l.internalf(format, v...)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "log: "+format+"\n", v...)
+}
+
+// attach records l as the Logger using r, see attacher.
+func (r *RotatingFile) attach(l *Logger) {
+	r.logger.Store(l)
+}
+
+// ReopenOnSignal calls Reopen whenever the process receives sig, like
+// syscall.SIGHUP, as sent by logrotate. Errors are reported like those while
+// rotating. The returned function stops listening for sig.
+func (r *RotatingFile) ReopenOnSignal(sig os.Signal) (stop func()) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sig)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			case <-c:
+				if err := r.Reopen(); err != nil {
+					r.reportf("reopening %s: %v", r.path, err)
+				}
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(done)
+		<-stopped
+	}
+}
+
+// open opens the file. It must be called with r.mu held.
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file = f
+	r.size = info.Size()
+	r.opened = time.Now()
+	return nil
+}
+
+// rotate renames the file to a backup and opens a new one. If no new file can
+// be opened, r.file is left nil. It must be called with r.mu held.
+func (r *RotatingFile) rotate() error {
+	err := r.file.Close()
+	r.file = nil
+	if err != nil {
+		// The file is unusable now; continue with the one at its path.
+		if oerr := r.open(); oerr != nil {
+			return errors.Join(err, oerr)
+		}
+		return err
+	}
+	ext := filepath.Ext(r.path)
+	backup := strings.TrimSuffix(r.path, ext) + "-" + time.Now().UTC().Format(backupTimeFormat) + ext
+	if err := os.Rename(r.path, backup); err != nil && !os.IsNotExist(err) {
+		// Keep writing to the current file.
+		if oerr := r.open(); oerr != nil {
+			return oerr
+		}
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	if r.opts.MaxAgeDays > 0 || r.opts.MaxBackups > 0 || r.opts.Compress {
+		r.pending.Add(1)
+		go func() {
+			defer r.pending.Done()
+			if err := r.removeBackups(); err != nil {
+				r.reportf("cleaning up backups of %s: %v", r.path, err)
+			}
+		}()
+	}
+	return nil
+}
+
+// backup is a backup of a RotatingFile.
+type backup struct {
+	path string
+	time time.Time
+}
+
+// backups returns the backups of the file, newest first.
+func (r *RotatingFile) backups() ([]backup, error) {
+	dir := filepath.Dir(r.path)
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(filepath.Base(r.path), ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []backup
+	for _, e := range entries {
+		name := e.Name()
+		ts, ok := strings.CutPrefix(name, prefix)
+		if !ok || e.IsDir() {
+			continue
+		}
+		ts = strings.TrimSuffix(strings.TrimSuffix(ts, ".gz"), ext)
+		t, err := time.Parse(backupTimeFormat, ts)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{filepath.Join(dir, name), t})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+	return backups, nil
+}
+
+// removeBackups removes the backups beyond the limits, and compresses the
+// others if requested.
+func (r *RotatingFile) removeBackups() error {
+	r.cleanup.Lock()
+	defer r.cleanup.Unlock()
+	backups, err := r.backups()
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-time.Duration(r.opts.MaxAgeDays) * 24 * time.Hour)
+	for i, b := range backups {
+		if r.opts.MaxBackups > 0 && i >= r.opts.MaxBackups || r.opts.MaxAgeDays > 0 && b.time.Before(cutoff) {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if r.opts.Compress && !strings.HasSuffix(b.path, ".gz") {
+			if err := compressFile(b.path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compressFile replaces the file at path with a gzip compressed copy, named
+// path.gz.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+".gz")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,119 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := NewRotatingFile(path, RotateOptions{MaxSizeMB: 1, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := []byte(strings.Repeat("x", 600<<10) + "\n")
+	for i := 0; i < 5; i++ {
+		if _, err := f.Write(entry); err != nil {
+			t.Fatal(err)
+		}
+		// Backups are named after the time of the rotation, in milliseconds.
+		time.Sleep(2 * time.Millisecond)
+	}
+	os.Remove(path)
+	if err := f.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("new\n"))
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	names, _ := filepath.Glob(filepath.Join(dir, "*"))
+	gz := 0
+	for _, n := range names {
+		if strings.HasSuffix(n, ".log.gz") {
+			gz++
+		}
+	}
+	if gz != 2 || len(names) != 3 {
+		t.Errorf("directory holds %v, want app.log and 2 compressed backups", names)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "new\n" {
+		t.Errorf("app.log holds %q, want %q", b, "new\n")
+	}
+}
+
+func TestRotatingFileInterval(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := NewRotatingFile(path, RotateOptions{Interval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("one\n"))
+	f.Write([]byte("two\n"))
+	f.mu.Lock()
+	f.opened = f.opened.Add(-time.Hour)
+	f.mu.Unlock()
+	f.Write([]byte("three\n"))
+	f.Write([]byte("four\n"))
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, _ := os.ReadFile(path); string(b) != "three\nfour\n" {
+		t.Errorf("app.log holds %q, want %q", b, "three\nfour\n")
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	if len(backups) != 1 {
+		t.Fatalf("directory holds backups %v, want 1", backups)
+	}
+	if b, _ := os.ReadFile(backups[0]); string(b) != "one\ntwo\n" {
+		t.Errorf("backup holds %q, want %q", b, "one\ntwo\n")
+	}
+}
+
+func TestRotatingFileErrors(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	path := filepath.Join(dir, "app.log")
+	f, err := NewRotatingFile(path, RotateOptions{Interval: time.Nanosecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var in syncBuffer
+	l := New(f, "", 0)
+	l.SetInternalOutput(&in)
+
+	// The directory is replaced by a file, so that the file can be neither
+	// renamed nor opened again.
+	l.Error("one")
+	os.RemoveAll(dir)
+	if err := os.WriteFile(dir, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("two\n")); err == nil {
+		t.Error("Write succeeded without a file")
+	}
+	if !strings.HasPrefix(in.String(), "log: rotating "+path+": ") {
+		t.Errorf("internal output = %q, want the rotation error", in.String())
+	}
+
+	// Once the directory can be created again, a new file is opened.
+	os.Remove(dir)
+	if _, err := f.Write([]byte("three\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("four\n")); err != os.ErrClosed {
+		t.Errorf("Write after Close returned %v, want %v", err, os.ErrClosed)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "three\n" {
+		t.Errorf("app.log holds %q, want %q", b, "three\n")
+	}
+}
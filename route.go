@@ -0,0 +1,65 @@
+package log
+
+import "io"
+
+// A LevelWriter is an output that is told the level of each entry, like a
+// SyslogWriter choosing the severity of the message. If the output of a
+// Logger implements LevelWriter, entries are written with WriteLevel instead
+// of Write; p then holds one entry, or all entries of a transaction, in which
+// case level is the most severe one. The same holds for the outputs added with
+// AddOutput. Output written with Logger.Write is passed to Write.
+type LevelWriter interface {
+	io.Writer
+	WriteLevel(level int, p []byte) (n int, err error)
+}
+
+// SetLevelOutput sets the output destination for entries at the given level,
+// in place of the output of the logger. For example, to write warnings and
+// errors to os.Stderr and other entries to os.Stdout:
+//
+//	l := log.New(os.Stdout, "", log.LstdFlags)
+//	for level := log.LevelFatal; level <= log.LevelWarn; level++ {
+//		l.SetLevelOutput(level, os.Stderr)
+//	}
+//
+// To write the entries to an additional destination instead, like a separate
+// error file, pass an io.MultiWriter of that destination and the output of the
+// logger. A nil w removes the override, so that entries at level are written
+// to the output of the logger again. Overrides are kept when the output is
+// changed with SetOutput. Write and Tx.Commit always use the output of the
+// logger. It panics if level is not registered.
+func (l *Logger) SetLevelOutput(level int, w io.Writer) {
+	if !validLevel(level) {
+		panic("invalid log level")
+	}
+	defer l.unlock(l.lock())
+	if w == nil {
+		l.route[level] = nil
+	} else {
+		l.route[level] = &countWriter{w: w}
+	}
+	l.updateOutputs()
+}
+
+func SetLevelOutput(level int, w io.Writer) {
+	std.SetLevelOutput(level, w)
+}
+
+// levelWriter returns the writer for entries at the given level. It must be
+// called with l.mu held.
+func (l *Logger) levelWriter(level int) *countWriter {
+	if cw := l.route[level]; cw != nil {
+		return cw
+	}
+	return l.cw
+}
+
+// leveledWriter writes to a LevelWriter at a fixed level.
+type leveledWriter struct {
+	w     LevelWriter
+	level int
+}
+
+func (w leveledWriter) Write(p []byte) (n int, err error) {
+	return w.w.WriteLevel(w.level, p)
+}
@@ -0,0 +1,155 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sampleSummaryInterval is the minimum time between the summaries of entries
+// dropped by a Sampler.
+const sampleSummaryInterval = 10 * time.Second
+
+// A Sampler decides which entries at a level are written, see SetSampler.
+// Sample reports whether an entry logged at time now from the call site pc,
+// the program counter of the call to the logging method, should be written.
+// Its decisions may be kept per call site, so that one noisy call site does
+// not suppress the entries of others. Sample may be called concurrently, as a
+// Sampler can be set for several levels and loggers.
+type Sampler interface {
+	Sample(pc uintptr, now time.Time) bool
+}
+
+// sampling is the Sampler of a level, and the entries it dropped since the
+// last summary. It is guarded by the mutex of the logger.
+type sampling struct {
+	s       Sampler
+	dropped int
+	since   time.Time // time of the first dropped entry
+}
+
+// SetSampler sets the Sampler that decides which entries at the given level
+// are written; a nil s removes it. Fatal and panic entries are never dropped.
+// When entries are dropped, a summary like
+//
+//	suppressed 412 similar entries in the last 10s
+//
+// is logged at the same level, at most once every 10 seconds, when an entry
+// at that level is logged. It panics if level is not registered.
+func (l *Logger) SetSampler(level int, s Sampler) {
+	if !validLevel(level) {
+		panic("invalid log level")
+	}
+	defer l.unlock(l.lock())
+	if s == nil {
+		l.sample[level] = nil
+	} else {
+		l.sample[level] = &sampling{s: s}
+	}
+}
+
+func SetSampler(level int, s Sampler) {
+	std.SetSampler(level, s)
+}
+
+// sampled reports whether the entry at the given level from the call site pc
+// is written, and logs a summary of the dropped entries if one is due. It must
+// be called by enabled.
+func (l *Logger) sampled(level int, sm *sampling, pc uintptr) bool {
+	now := l.now()
+	ok := sm.s.Sample(pc, now)
+	if !ok {
+		if sm.dropped == 0 {
+			sm.since = now
+		}
+		sm.dropped++
+	}
+	if sm.dropped > 0 && now.Sub(sm.since) >= sampleSummaryInterval {
+		s := fmt.Sprintf("suppressed %d similar entries in the last %v", sm.dropped, now.Sub(sm.since).Round(time.Second))
+		sm.dropped = 0
+		// Skip sampled and enabled, and attribute the summary to the caller of
+		// the logging method. The details collected for the entry being
+		// logged, like the fields of its errors, are kept for it.
+		extra := l.extra
+		l.extra = entryExtra{ctx: extra.ctx}
+		l.skip += 2
+		l.format(level, s)
+		l.skip -= 2
+		l.extra = extra
+	}
+	return ok
+}
+
+// everySampler is the Sampler returned by SampleEvery.
+type everySampler struct {
+	n     int64
+	sites sync.Map // map[uintptr]*atomic.Int64
+}
+
+// SampleEvery returns a Sampler that writes the first of every n entries from
+// each call site. If n is 1 or less, every entry is written.
+func SampleEvery(n int) Sampler {
+	return &everySampler{n: int64(n)}
+}
+
+func (s *everySampler) Sample(pc uintptr, now time.Time) bool {
+	if s.n <= 1 {
+		return true
+	}
+	v, ok := s.sites.Load(pc)
+	if !ok {
+		v, _ = s.sites.LoadOrStore(pc, new(atomic.Int64))
+	}
+	return (v.(*atomic.Int64).Add(1)-1)%s.n == 0
+}
+
+// rateSampler is the Sampler returned by RateLimit.
+type rateSampler struct {
+	rate  float64 // tokens per second
+	burst float64
+	sites sync.Map // map[uintptr]*bucket
+}
+
+// bucket is the token bucket of a call site.
+type bucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// RateLimit returns a Sampler that writes at most n entries per interval from
+// each call site, allowing bursts of up to burst entries. It is a token
+// bucket that starts full, holds burst tokens and gains n tokens per interval.
+// A burst less than 1 is taken to be 1.
+func RateLimit(n int, per time.Duration, burst int) Sampler {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateSampler{
+		rate:  float64(n) / per.Seconds(),
+		burst: float64(burst),
+	}
+}
+
+func (s *rateSampler) Sample(pc uintptr, now time.Time) bool {
+	v, ok := s.sites.Load(pc)
+	if !ok {
+		v, _ = s.sites.LoadOrStore(pc, &bucket{tokens: s.burst})
+	}
+	b := v.(*bucket)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * s.rate
+		if b.tokens > s.burst {
+			b.tokens = s.burst
+		}
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setStd replaces the standard logger by l for the duration of the test.
+func setStd(t *testing.T, l *Logger) {
+	old := std
+	SetStdLogger(l)
+	t.Cleanup(func() { SetStdLogger(old) })
+}
+
+func TestSampleEvery(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", Lshortfile)
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+	l.SetSampler(LevelError, SampleEvery(10))
+
+	for i := 0; i < 25; i++ {
+		l.Error("noisy ", i)
+		now = now.Add(time.Second)
+	}
+	l.Error("other")
+	got := buf.String()
+	if n := strings.Count(got, "noisy"); n != 3 {
+		t.Errorf("%d noisy entries written, want 3:\n%s", n, got)
+	}
+	if !strings.Contains(got, "sample_test.go:25: suppressed 10 similar entries in the last 10s") {
+		t.Errorf("no summary attributed to the call site:\n%s", got)
+	}
+	if !strings.Contains(got, "other") {
+		t.Errorf("entry from another call site was sampled:\n%s", got)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+	l.SetSampler(LevelError, RateLimit(1, time.Second, 3))
+
+	for i := 0; i < 10; i++ {
+		l.Error("burst ", i)
+	}
+	now = now.Add(2 * time.Second)
+	l.Error("burst after")
+	if got := buf.String(); strings.Count(got, "burst") != 4 {
+		t.Errorf("want the burst of 3 and 1 entry after it:\n%s", got)
+	}
+}
+
+func TestSamplePackageFunctions(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", Lshortfile)
+	l.SetLevel(LevelDebug)
+	setStd(t, l)
+	SetSampler(LevelError, SampleEvery(5))
+	SetSampler(LevelInfo, SampleEvery(5))
+
+	for i := 0; i < 10; i++ {
+		Error("error")
+		Infow("info")
+		Log(LevelError, "log")
+	}
+	got := buf.String()
+	for _, msg := range []string{"error", "info", "log"} {
+		if n := strings.Count(got, ": "+msg+"\n"); n != 2 {
+			t.Errorf("%d %s entries written, want 2:\n%s", n, msg, got)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package log
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// NewSlogBackend returns a new Logger that passes its entries to the slog
+// handler h, instead of formatting and writing them itself. Each entry becomes
+// an slog.Record with the corresponding slog level, the time of the entry, the
+// program counter of the caller and the fields of the entry as attributes;
+// groups become slog groups, and the code of entries logged by Errorc and the
+// like becomes a "code" attribute. Entries logged by a *Ctx method, like
+// InfoCtx, are handled with their context, so that the handler can read
+// values like trace and span IDs from it. The handler decides which levels are
+// enabled: entries at levels it does not handle are not formatted, and Enabled
+// reports them as disabled. The level of the returned Logger is LevelDebug.
+// Flags, prefix and Write have no effect on the output.
+func NewSlogBackend(h slog.Handler) *Logger {
+	cw := &countWriter{w: io.Discard}
+	l := &Logger{logger: &logger{
+		cw:   cw,
+		out:  io.Discard,
+		slog: h,
+		now:  time.Now,
+		slow: time.Second,
+		reg:  new(registry),
+	}}
+	l.prefix.Store(new(string))
+	l.level.Store(LevelDebug)
+	l.plevel.Store(LevelInfo)
+	l.updateOutputs()
+	return l
+}
+
+// slogLevel returns the slog level corresponding to a log level. Panic and
+// fatal levels are mapped above slog.LevelError, and levels above LevelDebug
+// below slog.LevelDebug.
+func slogLevel(level int) slog.Level {
+	switch level {
+	case LevelFatal:
+		return slog.LevelError + 8
+	case LevelPanic:
+		return slog.LevelError + 4
+	case LevelError:
+		return slog.LevelError
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelInfo:
+		return slog.LevelInfo
+	}
+	return slog.LevelDebug - 4*slog.Level(level-LevelDebug)
+}
+
+// slogEnabled reports whether the slog handler of l, if any, handles entries
+// at the given level.
+func (l *Logger) slogEnabled(level int) bool {
+	return l.slog == nil || l.slog.Enabled(context.Background(), slogLevel(level))
+}
+
+// handle passes an entry with message msg to the slog handler of l. It must be
+// called directly by format.
+func (l *Logger) handle(level int, msg string, fields []Field, extra entryExtra) {
+	var pcs [1]uintptr
+	runtime.Callers(4+l.skip, pcs[:]) // skip Callers, handle, format and the logging method
+	r := slog.NewRecord(l.now(), slogLevel(level), strings.TrimSuffix(msg, "\n"), pcs[0])
+	if extra.code != "" {
+		r.AddAttrs(slog.String("code", extra.code))
+	}
+	for _, f := range fields {
+		r.AddAttrs(l.slogAttr(f))
+	}
+	start := time.Now()
+	err := l.slog.Handle(extra.context(), r)
+	l.timeWrite(start)
+	l.health.record(err)
+	if err != nil {
+		l.internalf("handling entry: %v", err)
+		return
+	}
+	l.stats.add(level, int64(len(r.Message)))
+}
+
+// slogAttr returns the slog attribute for field f.
+func (l *Logger) slogAttr(f Field) slog.Attr {
+	value := l.resolve(f.Value)
+	if g, ok := value.(group); ok {
+		attrs := make([]interface{}, len(g))
+		for i, f := range g {
+			attrs[i] = l.slogAttr(f)
+		}
+		return slog.Group(f.Key, attrs...)
+	}
+	if h, ok := value.(humanizer); ok {
+		if l.human {
+			value = h.human()
+		} else {
+			value = h.raw()
+		}
+	}
+	return slog.Any(f.Key, value)
+}
@@ -0,0 +1,113 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingHandler is an slog.Handler that records the records it handles.
+type recordingHandler struct {
+	min     slog.Level
+	mu      sync.Mutex
+	records []slog.Record
+	ctxs    []context.Context
+}
+
+func (h *recordingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.min
+}
+
+func (h *recordingHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	h.ctxs = append(h.ctxs, ctx)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs(attrs []slog.Attr) slog.Handler { return h }
+func (h *recordingHandler) WithGroup(name string) slog.Handler       { return h }
+
+// attrs returns the attributes of r in the form key=value.
+func attrs(r slog.Record) []string {
+	var s []string
+	r.Attrs(func(a slog.Attr) bool {
+		s = append(s, a.String())
+		return true
+	})
+	return s
+}
+
+func TestSlogBackend(t *testing.T) {
+	h := &recordingHandler{min: slog.LevelInfo}
+	l := NewSlogBackend(h)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	l.With("user", "ann").Infow("logged in", "attempts", 2, Bytes("size", 2048))
+	l.Debug("hidden")
+	l.Errorln("failed")
+	if len(h.records) != 2 {
+		t.Fatalf("handler got %d records, want 2", len(h.records))
+	}
+
+	r := h.records[0]
+	if r.Message != "logged in" || r.Level != slog.LevelInfo || !r.Time.Equal(now) {
+		t.Errorf("record = %q at %v, %v", r.Message, r.Level, r.Time)
+	}
+	if got, want := strings.Join(attrs(r), " "), "user=ann attempts=2 size=2048"; got != want {
+		t.Errorf("attributes = %s, want %s", got, want)
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+	if !strings.HasSuffix(frame.File, "slog_test.go") {
+		t.Errorf("caller = %s:%d, want slog_test.go", frame.File, frame.Line)
+	}
+
+	r = h.records[1]
+	if r.Message != "failed" || r.Level != slog.LevelError || r.NumAttrs() != 0 {
+		t.Errorf("record = %q at %v with %d attributes", r.Message, r.Level, r.NumAttrs())
+	}
+}
+
+func TestSlogBackendEnabled(t *testing.T) {
+	h := &recordingHandler{min: slog.LevelWarn}
+	l := NewSlogBackend(h)
+	if l.Enabled(LevelInfo) {
+		t.Error("Enabled(LevelInfo) = true, but the handler does not handle info records")
+	}
+	if !l.Enabled(LevelError) {
+		t.Error("Enabled(LevelError) = false, but the handler handles error records")
+	}
+
+	// Disabled entries are not formatted at all.
+	formatted := false
+	l.InfoFunc(func() string {
+		formatted = true
+		return "expensive"
+	})
+	if formatted {
+		t.Error("entry at a level the handler does not handle was formatted")
+	}
+}
+
+func TestSlogBackendGroup(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlogBackend(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	l.Infow("request", "req", group{{"method", "GET"}, {"status", 200}})
+	if got, want := buf.String(), "level=INFO msg=request req.method=GET req.status=200\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
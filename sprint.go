@@ -0,0 +1,57 @@
+package log
+
+import (
+	"strings"
+)
+
+// Sprint formats an entry at the given level as l would write it, with its
+// header, label and fields, and returns it instead of writing it. Arguments are
+// handled in the manner of fmt.Print. The entry is not counted in the
+// statistics of l and is not passed to its error reporter. The caller in the
+// header is the caller of Sprint.
+func (l *Logger) Sprint(level int, v ...interface{}) string {
+	defer l.unlock(l.lock())
+	return l.sprintEntry(level, l.sprint(v...))
+}
+
+// Sprintf is like Sprint, but arguments are handled in the manner of
+// fmt.Printf.
+func (l *Logger) Sprintf(level int, format string, v ...interface{}) string {
+	defer l.unlock(l.lock())
+	return l.sprintEntry(level, l.sprintf(format, v...))
+}
+
+func Sprint(level int, v ...interface{}) string {
+	defer std.unlock(std.lock())
+	return std.sprintEntry(level, std.sprint(v...))
+}
+
+func Sprintf(level int, format string, v ...interface{}) string {
+	defer std.unlock(std.lock())
+	return std.sprintEntry(level, std.sprintf(format, v...))
+}
+
+// sprintEntry formats an entry like format, but returns it. It must be called
+// directly by Sprint or Sprintf, or with l.skip set accordingly.
+func (l *Logger) sprintEntry(level int, s string) string {
+	extra := l.extra
+	l.extra = entryExtra{}
+	fields := l.entryFields(extra)
+	if l.flag&LJSON != 0 {
+		return string(l.appendJSON(nil, level, sanitizeUTF8(s, l.utf8), fields, extra))
+	}
+	if l.flag&Llogfmt != 0 {
+		return string(l.appendLogfmt(nil, level, sanitizeUTF8(s, l.utf8), fields, extra))
+	}
+	if len(fields) > 0 {
+		s = appendMessage(s, string(appendFieldList(nil, fields, l)))
+	}
+	s = sanitizeUTF8(s, l.utf8)
+	s = l.render(level, s, extra)
+	if l.flag&Ldelta != 0 {
+		s = l.delta(false) + s
+	}
+	var b strings.Builder
+	l.writeEntry(&b, l.flag, l.Prefix(), 3+l.skip, s)
+	return b.String()
+}
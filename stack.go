@@ -0,0 +1,64 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+)
+
+// maxStackSize is the maximum size of the stacks logged by PrintStack.
+const maxStackSize = 4 << 20
+
+// PrintStack logs the stack of the calling goroutine at the given level, or the
+// stacks of all goroutines if all is true. The stacks are logged as a single
+// entry, truncated to 4 MiB; the frames of PrintStack itself are left out.
+func (l *Logger) PrintStack(level int, all bool) {
+	stack := captureStack(all, 2) // skip captureStack and PrintStack
+	defer l.unlock(l.lock())
+	if l.enabled(level) {
+		l.format(level, "stack:\n"+string(stack))
+	}
+}
+
+func PrintStack(level int, all bool) {
+	stack := captureStack(all, 2)
+	defer std.unlock(std.lock())
+	if std.enabled(level) {
+		std.format(level, "stack:\n"+string(stack))
+	}
+}
+
+// captureStack returns the stack of the calling goroutine, or of all goroutines,
+// without the first skip frames of the calling goroutine.
+func captureStack(all bool, skip int) []byte {
+	var stack []byte
+	truncated := false
+	for size := 64 << 10; ; size *= 2 {
+		buf := make([]byte, size)
+		n := runtime.Stack(buf, all)
+		if n < size {
+			stack = buf[:n]
+			break
+		}
+		if size >= maxStackSize {
+			stack, truncated = buf, true
+			break
+		}
+	}
+
+	// The calling goroutine comes first. Each of its frames takes two lines,
+	// after the "goroutine N [running]:" header.
+	if i := bytes.IndexByte(stack, '\n'); i >= 0 {
+		header, frames := stack[:i+1], stack[i+1:]
+		for n := 0; n < 2*skip; n++ {
+			if j := bytes.IndexByte(frames, '\n'); j >= 0 {
+				frames = frames[j+1:]
+			}
+		}
+		stack = append(header, frames...)
+	}
+	if truncated {
+		stack = fmt.Appendf(stack, "\n... (truncated at %d bytes)\n", maxStackSize)
+	}
+	return stack
+}
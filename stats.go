@@ -0,0 +1,156 @@
+package log
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// LevelStats contains the number of entries and bytes written by a Logger,
+// indexed by log level. Entries suppressed by the log level are not counted.
+//
+// It also contains the time spent writing entries to the output: the total, so
+// that the average can be computed, and the longest single write. Writes that
+// took longer than the slow write threshold are counted as SlowWrites.
+//
+// DroppedInternal counts the internal messages that were not written to the
+// internal output, see SetInternalOutput. DroppedEntries counts the entries
+// dropped by outputs that queue them, like an AsyncWriter, since the outputs
+// were created; it is not reset by ResetStats.
+type LevelStats struct {
+	Entries [MaxLevel + 1]uint64
+	Bytes   [MaxLevel + 1]uint64
+
+	WriteTime    time.Duration
+	MaxWriteTime time.Duration
+	SlowWrites   uint64
+
+	DroppedInternal uint64
+	DroppedEntries  uint64
+}
+
+// stats holds the counters behind LevelStats.
+type stats struct {
+	entries [MaxLevel + 1]atomic.Uint64
+	bytes   [MaxLevel + 1]atomic.Uint64
+	wtime   atomic.Int64
+	wmax    atomic.Int64
+	slow    atomic.Uint64
+	idrop   atomic.Uint64
+}
+
+func (s *stats) add(level int, n int64) {
+	s.entries[level].Add(1)
+	s.bytes[level].Add(uint64(n))
+}
+
+// write records a write that took d, and reports whether it took longer than
+// threshold. A zero threshold disables the check.
+func (s *stats) write(d, threshold time.Duration) bool {
+	s.wtime.Add(int64(d))
+	for {
+		max := s.wmax.Load()
+		if int64(d) <= max || s.wmax.CompareAndSwap(max, int64(d)) {
+			break
+		}
+	}
+	if threshold > 0 && d > threshold {
+		s.slow.Add(1)
+		return true
+	}
+	return false
+}
+
+// total returns the total number of entries written.
+func (s *stats) total() (n uint64) {
+	for i := range s.entries {
+		n += s.entries[i].Load()
+	}
+	return
+}
+
+// Stats returns a copy of the entry and byte counters of the logger.
+func (l *Logger) Stats() LevelStats {
+	var s LevelStats
+	for i := range s.Entries {
+		s.Entries[i] = l.stats.entries[i].Load()
+		s.Bytes[i] = l.stats.bytes[i].Load()
+	}
+	s.WriteTime = time.Duration(l.stats.wtime.Load())
+	s.MaxWriteTime = time.Duration(l.stats.wmax.Load())
+	s.SlowWrites = l.stats.slow.Load()
+	s.DroppedInternal = l.stats.idrop.Load()
+	if outs := l.outs.Load(); outs != nil {
+		for _, o := range *outs {
+			if d, ok := o.w.(dropper); ok {
+				s.DroppedEntries += uint64(d.Dropped())
+			}
+		}
+	}
+	return s
+}
+
+// ResetStats sets the counters of the logger to zero.
+func (l *Logger) ResetStats() {
+	for i := range l.stats.entries {
+		l.stats.entries[i].Store(0)
+		l.stats.bytes[i].Store(0)
+	}
+	l.stats.wtime.Store(0)
+	l.stats.wmax.Store(0)
+	l.stats.slow.Store(0)
+	l.stats.idrop.Store(0)
+}
+
+// SlowWriteThreshold returns the duration after which a write to the output is
+// considered slow.
+func (l *Logger) SlowWriteThreshold() (d time.Duration) {
+	defer l.unlock(l.lock())
+	return l.slow
+}
+
+// SetSlowWriteThreshold sets the duration after which a write to the output is
+// considered slow. Slow writes are counted in the SlowWrites statistic and
+// reported on the internal output, as in "log: write took 1.4s". The default is
+// one second; zero disables the check.
+func (l *Logger) SetSlowWriteThreshold(d time.Duration) {
+	defer l.unlock(l.lock())
+	l.slow = d
+}
+
+func SlowWriteThreshold() time.Duration {
+	return std.SlowWriteThreshold()
+}
+
+func SetSlowWriteThreshold(d time.Duration) {
+	std.SetSlowWriteThreshold(d)
+}
+
+// timeWrite records a write to the output that started at start.
+func (l *Logger) timeWrite(start time.Time) {
+	d := time.Since(start)
+	if l.stats.write(d, l.slow) {
+		l.internalf("write took %v", d)
+	}
+}
+
+// countWriter remembers the number of bytes of the last write to w. If w is a
+// LevelWriter, it is passed the level of the entry being written, which must be
+// set before each write with l.mu held.
+type countWriter struct {
+	w      io.Writer
+	last   atomic.Int64
+	level  int
+	health health
+}
+
+func (c *countWriter) Write(p []byte) (n int, err error) {
+	if lw, ok := c.w.(LevelWriter); ok {
+		n, err = lw.WriteLevel(c.level, p)
+	} else {
+		n, err = c.w.Write(p)
+	}
+	c.last.Store(int64(n))
+	c.health.record(err)
+	return
+}
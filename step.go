@@ -0,0 +1,92 @@
+package log
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// A Step reports the progress of a task, like "Building..." followed by
+// "Building: done (1.2s)". Create one with (*Logger).Step.
+type Step struct {
+	l     *Logger
+	name  string
+	start time.Time
+	mark  uint64
+	ended atomic.Bool
+}
+
+// Step logs "name..." at info level and returns a Step to report its outcome.
+// Entries logged until the step ends are indented one level.
+func (l *Logger) Step(name string) *Step {
+	defer l.unlock(l.lock())
+	if l.enabled(LevelInfo) {
+		l.format(LevelInfo, name+"...")
+	}
+	l.depth.Add(1)
+	return &Step{
+		l:     l,
+		name:  name,
+		start: l.now(),
+		mark:  l.stats.total(),
+	}
+}
+
+// SetStepRewrite sets whether the line of a step is rewritten in place when
+// the step ends, instead of logging a second line. This only happens if the
+// output is a terminal and nothing else was logged in the meantime.
+func (l *Logger) SetStepRewrite(enable bool) {
+	l.mu.Lock()
+	l.redraw = enable
+	l.mu.Unlock()
+}
+
+// Done logs that the step completed successfully.
+func (s *Step) Done() {
+	s.end(LevelInfo, colorGreen, "done")
+}
+
+// Fail logs at error level that the step failed with err. A nil err is
+// reported as a failure without a reason.
+func (s *Step) Fail(err error) {
+	if err == nil {
+		s.end(LevelError, colorRed, "failed")
+		return
+	}
+	s.end(LevelError, colorRed, "failed: "+err.Error())
+}
+
+// Skip logs that the step was skipped for the given reason.
+func (s *Step) Skip(reason string) {
+	s.end(LevelInfo, colorYellow, "skipped: "+reason)
+}
+
+func (s *Step) end(level, color int, status string) {
+	if s.ended.Swap(true) {
+		return
+	}
+	l := s.l
+	defer l.unlock(l.lock())
+	l.outdent()
+	if l.threshold() < level {
+		return
+	}
+	if l.colored() {
+		status = colorSeq(color) + status + colorSeq(colorNone)
+	}
+	if l.redraw && l.isTerm && l.flag&(LJSON|Llogfmt) == 0 && l.stats.total() == s.mark+1 {
+		// Move the cursor to the start of the previous line and clear it.
+		l.out.Write([]byte("\033[1A\r\033[2K"))
+	}
+	elapsed := l.now().Sub(s.start)
+	if elapsed < time.Second {
+		elapsed = elapsed.Round(time.Millisecond)
+	} else {
+		elapsed = elapsed.Round(100 * time.Millisecond)
+	}
+	l.format(level, fmt.Sprintf("%s: %s (%s)", s.name, status, elapsed))
+}
+
+func SetStepRewrite(enable bool) {
+	std.SetStepRewrite(enable)
+}
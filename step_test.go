@@ -0,0 +1,31 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStep(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	l.SetLevel(LevelInfo)
+	l.now = func() time.Time { return time.Unix(0, 0) }
+
+	s := l.Step("Building")
+	l.Info("compiling")
+	s.Done()
+	l.Step("Testing").Fail(errors.New("2 tests failed"))
+	l.Step("Deploying").Fail(nil)
+	want := "Building...\n" +
+		"  compiling\n" +
+		"Building: done (0s)\n" +
+		"Testing...\n" +
+		"Testing: failed: 2 tests failed (0s)\n" +
+		"Deploying...\n" +
+		"Deploying: failed (0s)\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
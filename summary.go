@@ -0,0 +1,76 @@
+package log
+
+import (
+	"strconv"
+	"strings"
+)
+
+// summaryNames contains the singular and plural names of the levels in a
+// summary.
+var summaryNames = [...][2]string{
+	LevelFatal: {"fatal", "fatal"},
+	LevelPanic: {"panic", "panics"},
+	LevelError: {"error", "errors"},
+	LevelWarn:  {"warning", "warnings"},
+	LevelInfo:  {"info", "info"},
+	LevelDebug: {"debug", "debug"},
+}
+
+// LogSummary logs the number of entries written per level as a single entry at
+// the given level, like "log summary: 2 errors, 14 warnings, 1203 info". Levels
+// without entries are left out.
+func (l *Logger) LogSummary(level int) {
+	if l.suppressed(level) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(level) {
+		l.format(level, l.summary())
+	}
+}
+
+func LogSummary(level int) {
+	if std.suppressed(level) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(level) {
+		std.format(level, std.summary())
+	}
+}
+
+// SummaryOnExit sets whether the Fatal methods log the summary of LogSummary
+// before exiting. It is logged at info level, even if that level is disabled.
+func (l *Logger) SummaryOnExit(enable bool) {
+	defer l.unlock(l.lock())
+	l.sumx = enable
+}
+
+func SummaryOnExit(enable bool) {
+	std.SummaryOnExit(enable)
+}
+
+// summary returns the message logged by LogSummary.
+func (l *Logger) summary() string {
+	var parts []string
+	for level := range l.stats.entries {
+		n := l.stats.entries[level].Load()
+		if n == 0 {
+			continue
+		}
+		var name string
+		if level < len(summaryNames) {
+			name = summaryNames[level][1]
+			if n == 1 {
+				name = summaryNames[level][0]
+			}
+		} else {
+			name = strings.ToLower(levelName(level))
+		}
+		parts = append(parts, strconv.FormatUint(n, 10)+" "+name)
+	}
+	if len(parts) == 0 {
+		return "log summary: no entries"
+	}
+	return "log summary: " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,54 @@
+package log_test
+
+import (
+	"testing"
+
+	"github.com/semrekkers/log"
+	"github.com/semrekkers/log/logtest"
+)
+
+func TestLogSummary(t *testing.T) {
+	r := logtest.Capture(t)
+	log.LogSummary(log.LevelInfo)
+	log.Error("e1")
+	log.Error("e2")
+	log.Warn("w")
+	log.Debug("d")
+	log.LogSummary(log.LevelWarn)
+
+	lines := r.Lines()
+	want := []string{
+		"[INFO ] log summary: no entries",
+		"[ERROR] e1",
+		"[ERROR] e2",
+		"[WARN ] w",
+		"[DEBUG] d",
+		"[WARN ] log summary: 2 errors, 1 warning, 1 info, 1 debug",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("lines = %q, want %q", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestSummaryOnExit(t *testing.T) {
+	r := logtest.Capture(t)
+	log.SetLevel(log.LevelWarn)
+	log.SummaryOnExit(true)
+	log.Warn("w1")
+	log.Warn("w2")
+	log.Info("hidden")
+	log.Fatal("bye")
+
+	if code, ok := r.Exited(); !ok || code != 1 {
+		t.Errorf("Exited() = %d, %t, want 1, true", code, ok)
+	}
+	lines := r.Lines()
+	if got, want := lines[len(lines)-1], "[INFO ] log summary: 1 fatal, 2 warnings"; got != want {
+		t.Errorf("last line = %q, want %q", got, want)
+	}
+}
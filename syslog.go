@@ -0,0 +1,179 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"fmt"
+	"log/syslog"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// syslogQueue is the number of entries a SyslogWriter keeps while the
+	// syslog daemon cannot be reached.
+	syslogQueue = 1000
+
+	// maxSyslogBackoff is the maximum time between attempts to reconnect to
+	// the syslog daemon.
+	maxSyslogBackoff = time.Minute
+)
+
+// A SyslogWriter is a LevelWriter that writes entries to the syslog daemon,
+// or journald on systemd hosts, with the severity of their level: LevelFatal
+// and LevelPanic map to LOG_CRIT, LevelError to LOG_ERR, LevelWarn to
+// LOG_WARNING, LevelInfo to LOG_INFO, and LevelDebug and the custom levels
+// below it to LOG_DEBUG.
+//
+// If the daemon cannot be reached, the entries are queued and the writer
+// reconnects with exponential backoff, up to a minute between attempts. When
+// the connection is restored, the queued entries are written first. At most
+// 1000 entries are queued; older ones are dropped when it is full, which is
+// reported as a write error and noted in the log once the connection is
+// restored.
+type SyslogWriter struct {
+	mu      sync.Mutex
+	network string
+	raddr   string
+	tag     string
+	w       *syslog.Writer // nil while disconnected
+	queue   []syslogEntry
+	dropped int
+	backoff time.Duration
+	retry   time.Time
+}
+
+type syslogEntry struct {
+	level int
+	msg   string
+}
+
+// NewSyslogWriter returns a SyslogWriter connected to the syslog daemon at
+// raddr on the given network, as for syslog.Dial. If network is empty, it
+// connects to the local daemon. Each message is tagged with tag, or the name
+// of the program if tag is empty.
+func NewSyslogWriter(network, raddr, tag string) (*SyslogWriter, error) {
+	w, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogWriter{network: network, raddr: raddr, tag: tag, w: w}, nil
+}
+
+// Write writes p with the severity of LevelInfo.
+func (s *SyslogWriter) Write(p []byte) (n int, err error) {
+	return s.WriteLevel(LevelInfo, p)
+}
+
+// WriteLevel writes p with the severity of level, or queues it if the syslog
+// daemon cannot be reached.
+func (s *SyslogWriter) WriteLevel(level int, p []byte) (n int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := syslogEntry{level, strings.TrimSuffix(string(p), "\n")}
+	if s.w == nil && !s.reconnect() {
+		return len(p), s.enqueue(e)
+	}
+	for len(s.queue) > 0 {
+		if err := s.send(s.queue[0]); err != nil {
+			s.disconnect()
+			return len(p), s.enqueue(e)
+		}
+		s.queue = s.queue[1:]
+	}
+	if s.dropped > 0 {
+		note := fmt.Sprintf("%d entries dropped while syslog was unreachable", s.dropped)
+		if s.send(syslogEntry{LevelWarn, note}) == nil {
+			s.dropped = 0
+		}
+	}
+	if err := s.send(e); err != nil {
+		s.disconnect()
+		return len(p), s.enqueue(e)
+	}
+	return len(p), nil
+}
+
+// Close closes the connection to the syslog daemon. Queued entries are lost.
+func (s *SyslogWriter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = nil
+	if s.w == nil {
+		return nil
+	}
+	err := s.w.Close()
+	s.w = nil
+	return err
+}
+
+// send writes e with its severity. It must be called with s.mu held.
+func (s *SyslogWriter) send(e syslogEntry) error {
+	switch {
+	case e.level <= LevelPanic:
+		return s.w.Crit(e.msg)
+	case e.level == LevelError:
+		return s.w.Err(e.msg)
+	case e.level == LevelWarn:
+		return s.w.Warning(e.msg)
+	case e.level == LevelInfo:
+		return s.w.Info(e.msg)
+	}
+	return s.w.Debug(e.msg)
+}
+
+// reconnect tries to connect to the syslog daemon, unless the next attempt is
+// not due yet. It must be called with s.mu held.
+func (s *SyslogWriter) reconnect() bool {
+	now := time.Now()
+	if now.Before(s.retry) {
+		return false
+	}
+	w, err := syslog.Dial(s.network, s.raddr, syslog.LOG_INFO|syslog.LOG_USER, s.tag)
+	if err != nil {
+		s.backoff *= 2
+		if s.backoff == 0 {
+			s.backoff = time.Second
+		} else if s.backoff > maxSyslogBackoff {
+			s.backoff = maxSyslogBackoff
+		}
+		s.retry = now.Add(s.backoff)
+		return false
+	}
+	s.w = w
+	s.backoff = 0
+	return true
+}
+
+// disconnect closes the failed connection, so that the next write reconnects.
+// It must be called with s.mu held.
+func (s *SyslogWriter) disconnect() {
+	s.w.Close()
+	s.w = nil
+	s.retry = time.Time{}
+}
+
+// enqueue queues e until the connection is restored, dropping the oldest entry
+// if the queue is full. It must be called with s.mu held.
+func (s *SyslogWriter) enqueue(e syslogEntry) error {
+	if len(s.queue) < syslogQueue {
+		s.queue = append(s.queue, e)
+		return nil
+	}
+	s.queue = append(s.queue[1:], e)
+	s.dropped++
+	return fmt.Errorf("log: syslog unreachable, %d entries dropped", s.dropped)
+}
+
+// UseSyslog sets w as the output of the logger. The date, time and color
+// flags are cleared, since the syslog daemon adds its own timestamps and
+// severities; they may be set again with SetFlags.
+func (l *Logger) UseSyslog(w *SyslogWriter) {
+	l.SetOutput(w)
+	l.SetFlags(l.Flags() &^ (Ldate | Ltime | Lmicroseconds | Lcolor | LcolorAuto))
+}
+
+func UseSyslog(w *SyslogWriter) {
+	std.UseSyslog(w)
+}
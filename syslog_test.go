@@ -0,0 +1,49 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogWriter(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	w, err := NewSyslogWriter("udp", conn.LocalAddr().String(), "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	l := New(nil, "", Llabel)
+	l.UseSyslog(w)
+	l.SetLevel(LevelDebug)
+
+	l.Error("boom")
+	l.Warn("careful")
+	l.Info("fyi")
+	l.Debug("details")
+	// The priority is the facility, LOG_USER (1), times 8 plus the severity.
+	for _, want := range []struct{ pri, msg string }{
+		{"<11>", "[ERROR] boom\n"},
+		{"<12>", "[WARN ] careful\n"},
+		{"<14>", "[INFO ] fyi\n"},
+		{"<15>", "[DEBUG] details\n"},
+	} {
+		buf := make([]byte, 1024)
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := string(buf[:n])
+		if !strings.HasPrefix(got, want.pri) || !strings.Contains(got, " app[") || !strings.HasSuffix(got, want.msg) {
+			t.Errorf("got %q, want priority %s, tag app and message %q", got, want.pri, want.msg)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package log
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// tee is an additional output of a logger, with its own flags.
+type tee struct {
+	w      io.Writer
+	flag   int
+	isTerm bool
+	autoc  bool
+	last   atomic.Int64 // for Ldelta, see delta
+	health health
+}
+
+// AddOutput adds w as an additional output of the logger, which receives
+// every entry written to the output set with SetOutput, formatted according
+// to flag instead of the flags of the logger. For example, to write colored
+// entries to a terminal and JSON entries to a file:
+//
+//	l := log.New(os.Stderr, "", log.Ltime|log.Llabel|log.LcolorAuto)
+//	l.AddOutput(file, log.LJSON)
+//
+// The message and fields of an entry are built once, and only the header and
+// the formatting set by flag are applied for each output. Entries appear in
+// the same order on all outputs. A failed write to one output does not keep
+// the entry from the others; the error is passed to the function set with
+// SetOutputErrorHandler, or written to the internal output. Adding an output
+// that was already added replaces its flags.
+func (l *Logger) AddOutput(w io.Writer, flag int) {
+	defer l.unlock(l.lock())
+	t := &tee{
+		w:      w,
+		flag:   flag,
+		isTerm: isTerm(w),
+		autoc:  autoColor(w),
+	}
+	tees := make([]*tee, 0, len(l.tees)+1)
+	for _, o := range l.tees {
+		if o.w != w {
+			tees = append(tees, o)
+		}
+	}
+	l.tees = append(tees, t)
+	l.updateOutputs()
+}
+
+// RemoveOutput removes the output w added with AddOutput. It does nothing if
+// w was not added.
+func (l *Logger) RemoveOutput(w io.Writer) {
+	defer l.unlock(l.lock())
+	tees := make([]*tee, 0, len(l.tees))
+	for _, o := range l.tees {
+		if o.w != w {
+			tees = append(tees, o)
+		}
+	}
+	l.tees = tees
+	l.updateOutputs()
+}
+
+// SetOutputErrorHandler sets fn to be called with the outputs added with
+// AddOutput that fail to write an entry, and the error. It is called while
+// the logger is locked, so it must not log through l. A nil fn restores the
+// default, which writes the errors to the internal output.
+func (l *Logger) SetOutputErrorHandler(fn func(w io.Writer, err error)) {
+	defer l.unlock(l.lock())
+	l.teeerr = fn
+}
+
+func AddOutput(w io.Writer, flag int) {
+	std.AddOutput(w, flag)
+}
+
+func RemoveOutput(w io.Writer) {
+	std.RemoveOutput(w)
+}
+
+func SetOutputErrorHandler(fn func(w io.Writer, err error)) {
+	std.SetOutputErrorHandler(fn)
+}
+
+// writeTees writes an entry to the outputs added with AddOutput. It takes the
+// message before rendering, as passed to render and appendJSON. It must be
+// called directly by format.
+func (l *Logger) writeTees(level int, s, msg string, fields []Field, extra entryExtra) {
+	flag, out, isTerm, autoc := l.flag, l.out, l.isTerm, l.autoc
+	defer func() {
+		l.flag, l.out, l.isTerm, l.autoc = flag, out, isTerm, autoc
+	}()
+	// Attribute the header to the caller of the logging method, as if the
+	// entry was formatted by format itself.
+	l.skip++
+	defer func() { l.skip-- }()
+	for _, t := range l.tees {
+		l.flag, l.out, l.isTerm, l.autoc = t.flag, t.w, t.isTerm, t.autoc
+		w := t.w
+		if lw, ok := w.(LevelWriter); ok {
+			w = leveledWriter{lw, level}
+		}
+		var err error
+		if t.flag&LJSON != 0 {
+			_, err = w.Write(l.appendJSON(nil, level, sanitizeUTF8(msg, l.utf8), fields, extra))
+		} else if t.flag&Llogfmt != 0 {
+			_, err = w.Write(l.appendLogfmt(nil, level, sanitizeUTF8(msg, l.utf8), fields, extra))
+		} else {
+			e := l.render(level, s, extra)
+			if t.flag&Ldelta != 0 {
+				e = deltaSince(&t.last, true) + e
+			}
+			err = l.writeEntry(w, t.flag, l.Prefix(), 3+l.skip, e)
+		}
+		t.health.record(err)
+		if err != nil {
+			if l.teeerr != nil {
+				l.teeerr(t.w, err)
+			} else {
+				l.internalf("writing entry to additional output: %v", err)
+			}
+		}
+	}
+}
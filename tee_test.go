@@ -0,0 +1,83 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("write failed") }
+
+func TestAddOutput(t *testing.T) {
+	var text, js bytes.Buffer
+	l := New(&text, "", Llabel|Lcolor|Lshortfile)
+	l.AddOutput(failingWriter{}, 0)
+	l.AddOutput(&js, LJSON|Lshortfile)
+	var errs []error
+	l.SetOutputErrorHandler(func(w io.Writer, err error) { errs = append(errs, err) })
+
+	l.With("k", 1).Error("hello")
+	l.RemoveOutput(failingWriter{})
+	l.Error("two")
+	if got, want := text.String(), "tee_test.go:24: [\x1b[31mERROR\x1b[0m] hello k=1\n"+
+		"tee_test.go:26: [\x1b[31mERROR\x1b[0m] two\n"; got != want {
+		t.Errorf("text output = %q, want %q", got, want)
+	}
+	if got := js.String(); strings.Contains(got, "\x1b[") ||
+		!strings.Contains(got, `"file":"tee_test.go","line":24`) ||
+		!strings.Contains(got, `"msg":"hello","k":1}`) ||
+		strings.Count(got, "\n") != 2 {
+		t.Errorf("JSON output = %q", got)
+	}
+	if len(errs) != 1 {
+		t.Errorf("error handler called %d times, want once", len(errs))
+	}
+}
+
+func TestAddOutputReplaces(t *testing.T) {
+	var text, extra bytes.Buffer
+	l := New(&text, "", 0)
+	l.AddOutput(&extra, Llabel)
+	l.AddOutput(&extra, Llogfmt)
+
+	l.Error("once")
+	if got, want := extra.String(), "level=error msg=once\n"; got != want {
+		t.Errorf("additional output = %q, want %q", got, want)
+	}
+}
+
+// levelRecorder is a LevelWriter that records the levels of the entries
+// written to it.
+type levelRecorder struct {
+	bytes.Buffer
+	levels []int
+}
+
+func (r *levelRecorder) WriteLevel(level int, p []byte) (int, error) {
+	r.levels = append(r.levels, level)
+	return r.Write(p)
+}
+
+func TestAddOutputLevelWriter(t *testing.T) {
+	var text, js levelRecorder
+	l := New(io.Discard, "", 0)
+	l.SetLevel(LevelInfo)
+	l.AddOutput(&text, Llabel)
+	l.AddOutput(&js, LJSON)
+
+	l.Error("failed")
+	l.Info("done")
+	for _, rec := range []*levelRecorder{&text, &js} {
+		if len(rec.levels) != 2 || rec.levels[0] != LevelError || rec.levels[1] != LevelInfo {
+			t.Errorf("levels written %v, want [%d %d]", rec.levels, LevelError, LevelInfo)
+		}
+	}
+	if got, want := text.String(), "[ERROR] failed\n[INFO ] done\n"; got != want {
+		t.Errorf("text output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package log
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxTemplates is the maximum number of parsed templates that are cached, so
+// that programs building templates at run time do not grow the cache forever.
+const maxTemplates = 1000
+
+var (
+	// templates caches parsed message templates by their template string.
+	templates sync.Map
+	// ntemplates is the number of templates in the cache.
+	ntemplates atomic.Int64
+)
+
+// segment is a part of a parsed message template: either literal text or the
+// name of a placeholder.
+type segment struct {
+	text        string
+	placeholder bool
+}
+
+// parseTemplate splits a message template into literal text and {name}
+// placeholders. Parsed templates are cached, up to maxTemplates of them.
+func parseTemplate(template string) []segment {
+	if v, ok := templates.Load(template); ok {
+		return v.([]segment)
+	}
+	var segs []segment
+	s := template
+	for {
+		i := strings.IndexByte(s, '{')
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(s[i+1:], '}')
+		if j < 0 {
+			break
+		}
+		name := s[i+1 : i+1+j]
+		if name == "" || strings.ContainsAny(name, "{ ") {
+			segs = append(segs, segment{text: s[:i+1]})
+			s = s[i+1:]
+			continue
+		}
+		if i > 0 {
+			segs = append(segs, segment{text: s[:i]})
+		}
+		segs = append(segs, segment{text: name, placeholder: true})
+		s = s[i+j+2:]
+	}
+	if s != "" {
+		segs = append(segs, segment{text: s})
+	}
+	if ntemplates.Load() < maxTemplates {
+		if _, loaded := templates.LoadOrStore(template, segs); !loaded {
+			ntemplates.Add(1)
+		}
+	}
+	return segs
+}
+
+// renderTemplate renders a message template, replacing {name} placeholders by
+// the values of the corresponding fields, formatted according to the settings
+// of l. Placeholders without a field are left as they are, and fields without a
+// placeholder are appended as key=value pairs.
+func renderTemplate(template string, fields Fields, l *Logger) string {
+	b, used := expandTemplate(template, fields, l)
+	return string(appendFields(b, fields, used, l))
+}
+
+// template returns the message of an entry logged with a message template, like
+// renderTemplate, and adds the fields to the entry being logged: with LJSON or
+// Llogfmt, or an slog handler, all of them, and otherwise those without a
+// placeholder. It must be called with l.mu held.
+func (l *Logger) template(template string, fields Fields) string {
+	b, used := expandTemplate(template, fields, l)
+	if l.flag&(LJSON|Llogfmt) != 0 || l.slog != nil {
+		used = nil
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		if !used[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		l.extra.fields = append(l.extra.fields, Field{k, fields[k]})
+	}
+	return string(b)
+}
+
+// expandTemplate replaces the placeholders of a message template by the values
+// of the corresponding fields, and returns the result and the names of the
+// fields that were used.
+func expandTemplate(template string, fields Fields, l *Logger) (b []byte, used map[string]bool) {
+	used = make(map[string]bool)
+	for _, seg := range parseTemplate(template) {
+		if seg.placeholder {
+			if v, ok := fields[seg.text]; ok {
+				b = append(b, l.fieldString(v)...)
+				used[seg.text] = true
+				continue
+			}
+			b = append(b, '{')
+			b = append(b, seg.text...)
+			b = append(b, '}')
+			continue
+		}
+		b = append(b, seg.text...)
+	}
+	return b, used
+}
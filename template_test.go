@@ -0,0 +1,37 @@
+package log
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+
+	l.Errort("user {user} from {ip} {missing} {} {a b}", Fields{"user": "bob", "ip": "1.2.3.4", "extra": "x y", "n": 3})
+	if got, want := buf.String(), "user bob from 1.2.3.4 {missing} {} {a b} extra=\"x y\" n=3\n"; got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestTemplateJSON(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", LJSON)
+
+	l.With("svc", "api").Errort("user {user} logged in", Fields{"user": "bob", "n": 3})
+	if got, want := buf.String(), `{"level":"error","msg":"user bob logged in","svc":"api","n":3,"user":"bob"}`+"\n"; got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestTemplateCache(t *testing.T) {
+	l := New(&bytes.Buffer{}, "", 0)
+	for i := 0; i < 2*maxTemplates; i++ {
+		l.Errort("entry "+strconv.Itoa(i)+" {n}", Fields{"n": i})
+	}
+	if n := ntemplates.Load(); n > maxTemplates {
+		t.Errorf("%d templates cached, want at most %d", n, maxTemplates)
+	}
+}
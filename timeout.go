@@ -0,0 +1,181 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClosed is returned by the writes to a TimeoutWriter that is closed.
+var ErrClosed = errors.New("log: timeout writer is closed")
+
+// probeInterval is the time after which a TimeoutWriter retries an output that
+// supports write deadlines once it has been marked unhealthy.
+const probeInterval = time.Second
+
+// A TimeoutWriter writes to an output, but gives up on writes that do not
+// complete within a timeout. The data of such a write is written to a fallback
+// writer instead, and the output is marked unhealthy: subsequent writes go to
+// the fallback directly until the output has recovered.
+//
+// If the output supports write deadlines, like a net.Conn, the deadline is used
+// to abort the write and the output is retried after a second. Other writers are
+// written to by a single background goroutine; when a write times out the
+// goroutine keeps waiting for it, and the output is considered healthy again as
+// soon as the write completes. The data of that write may then end up in both
+// the output and the fallback.
+//
+// A TimeoutWriter can be used simultaneously from multiple goroutines.
+type TimeoutWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	fallback io.Writer
+	timeout  time.Duration
+	healthy  atomic.Bool
+	retry    time.Time
+	reqs     chan *writeReq
+	once     sync.Once
+	closed   bool
+}
+
+type writeReq struct {
+	p    []byte
+	done chan error
+
+	// The writer and the background goroutine agree on whether the write
+	// timed out before it finished, so that the output is not left marked
+	// unhealthy after it has recovered.
+	mu       sync.Mutex
+	finished bool
+	timedOut bool
+}
+
+// deadlineWriter is implemented by writers that support write deadlines.
+type deadlineWriter interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+// NewTimeoutWriter returns a TimeoutWriter that writes to w with the given
+// timeout. If fallback is nil, os.Stderr is used.
+func NewTimeoutWriter(w io.Writer, timeout time.Duration, fallback io.Writer) *TimeoutWriter {
+	if fallback == nil {
+		fallback = os.Stderr
+	}
+	t := &TimeoutWriter{
+		w:        w,
+		fallback: fallback,
+		timeout:  timeout,
+	}
+	t.healthy.Store(true)
+	return t
+}
+
+// Healthy reports whether the output is currently being written to.
+func (t *TimeoutWriter) Healthy() bool {
+	return t.healthy.Load()
+}
+
+// Write writes p to the output, or to the fallback if the output is unhealthy
+// or the write times out.
+func (t *TimeoutWriter) Write(p []byte) (n int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return 0, ErrClosed
+	}
+	if d, ok := t.w.(deadlineWriter); ok {
+		return t.writeDeadline(d, p)
+	}
+	return t.writeAsync(p)
+}
+
+func (t *TimeoutWriter) writeDeadline(d deadlineWriter, p []byte) (n int, err error) {
+	if !t.healthy.Load() && time.Now().Before(t.retry) {
+		return t.fallback.Write(p)
+	}
+	if err = d.SetWriteDeadline(time.Now().Add(t.timeout)); err != nil {
+		return t.w.Write(p)
+	}
+	n, err = t.w.Write(p)
+	d.SetWriteDeadline(time.Time{})
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		t.healthy.Store(false)
+		t.retry = time.Now().Add(probeInterval)
+		return t.fallback.Write(p)
+	}
+	t.healthy.Store(true)
+	return n, err
+}
+
+func (t *TimeoutWriter) writeAsync(p []byte) (n int, err error) {
+	if !t.healthy.Load() {
+		return t.fallback.Write(p)
+	}
+	t.once.Do(t.start)
+
+	// The write may outlive this call, so it gets its own copy of p.
+	req := &writeReq{
+		p:    append([]byte(nil), p...),
+		done: make(chan error, 1),
+	}
+	t.reqs <- req
+
+	timer := time.NewTimer(t.timeout)
+	defer timer.Stop()
+	select {
+	case err = <-req.done:
+		if err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	case <-timer.C:
+		req.mu.Lock()
+		if req.finished {
+			req.mu.Unlock()
+			if err = <-req.done; err != nil {
+				return 0, err
+			}
+			return len(p), nil
+		}
+		req.timedOut = true
+		t.healthy.Store(false)
+		req.mu.Unlock()
+		return t.fallback.Write(p)
+	}
+}
+
+func (t *TimeoutWriter) start() {
+	reqs := make(chan *writeReq)
+	t.reqs = reqs
+	go func() {
+		for req := range reqs {
+			_, err := t.w.Write(req.p)
+			req.mu.Lock()
+			req.finished = true
+			if req.timedOut {
+				t.healthy.Store(true)
+			}
+			req.mu.Unlock()
+			req.done <- err
+		}
+	}()
+}
+
+// Close stops the background goroutine, if any. It does not close the output.
+// Later writes fail with ErrClosed.
+func (t *TimeoutWriter) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	t.once.Do(func() {})
+	if t.reqs != nil {
+		close(t.reqs)
+		t.reqs = nil
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package log
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gateWriter writes to buf once gate is closed.
+type gateWriter struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestTimeoutWriter(t *testing.T) {
+	w := &gateWriter{gate: make(chan struct{})}
+	var fallback bytes.Buffer
+	tw := NewTimeoutWriter(w, 20*time.Millisecond, &fallback)
+	defer tw.Close()
+
+	tw.Write([]byte("a\n"))
+	if tw.Healthy() {
+		t.Fatal("healthy after a write timed out")
+	}
+	tw.Write([]byte("b\n"))
+	close(w.gate)
+	deadline := time.Now().Add(2 * time.Second)
+	for !tw.Healthy() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !tw.Healthy() {
+		t.Fatal("not healthy after the output recovered")
+	}
+	tw.Write([]byte("c\n"))
+	if got, want := fallback.String(), "a\nb\n"; got != want {
+		t.Errorf("fallback = %q, want %q", got, want)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if got, want := w.buf.String(), "a\nc\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestTimeoutWriterConcurrent(t *testing.T) {
+	w := &gateWriter{gate: make(chan struct{})}
+	close(w.gate)
+	tw := NewTimeoutWriter(w, time.Millisecond, &syncBuffer{})
+	defer tw.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				tw.Write([]byte("x\n"))
+				tw.Healthy()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTimeoutWriterClosed(t *testing.T) {
+	w := &gateWriter{gate: make(chan struct{})}
+	close(w.gate)
+	tw := NewTimeoutWriter(w, time.Second, &bytes.Buffer{})
+	tw.Write([]byte("a\n"))
+	tw.Close()
+	tw.Close()
+
+	done := make(chan error)
+	go func() {
+		_, err := tw.Write([]byte("b\n"))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != ErrClosed {
+			t.Errorf("Write after Close returned %v, want ErrClosed", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Write after Close blocked")
+	}
+}
+
+// sleepWriter takes d to write, and reports each finished write on done.
+type sleepWriter struct {
+	d    time.Duration
+	done chan struct{}
+}
+
+func (w *sleepWriter) Write(p []byte) (int, error) {
+	time.Sleep(w.d)
+	w.done <- struct{}{}
+	return len(p), nil
+}
+
+func TestTimeoutWriterRecoverRace(t *testing.T) {
+	// Writes that finish right as they time out must not leave the output
+	// marked unhealthy once they are done.
+	w := &sleepWriter{d: time.Millisecond, done: make(chan struct{}, 1)}
+	tw := NewTimeoutWriter(w, time.Millisecond, &syncBuffer{})
+	defer tw.Close()
+	for i := 0; i < 300; i++ {
+		tw.Write([]byte("x\n"))
+		<-w.done
+		deadline := time.Now().Add(time.Second)
+		for !tw.Healthy() && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Microsecond)
+		}
+		if !tw.Healthy() {
+			t.Fatalf("write %d: not healthy after the write finished", i)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package log
+
+import (
+	"strconv"
+	"time"
+)
+
+// maxTxSize is the maximum size of the entries of a transaction.
+const maxTxSize = 1 << 20
+
+// A Tx is a transaction: a group of entries that is written as one contiguous
+// block, so that entries from other goroutines do not end up in between. Its
+// methods record entries like those of Logger, but keep them until Commit. Each
+// entry has the time at which it was recorded. Create one with Begin.
+//
+// A Tx holds at most 1 MiB of entries; further entries are dropped, which is
+// noted when the transaction is committed. A Tx must not be used from multiple
+// goroutines simultaneously, nor after Commit or Discard.
+type Tx struct {
+	l       *Logger
+	buf     []byte
+	levels  []int
+	sizes   []int
+	dropped int
+	done    bool
+}
+
+// Begin starts a transaction. The logger is not locked until Commit, so l can
+// be used as usual in the meantime.
+func (l *Logger) Begin() *Tx {
+	return &Tx{l: l}
+}
+
+func Begin() *Tx {
+	return std.Begin()
+}
+
+// Commit writes the entries of the transaction to the output of the logger in
+// a single write, and ends the transaction.
+func (t *Tx) Commit() error {
+	if t.done {
+		return nil
+	}
+	t.done = true
+	l := t.l
+	defer l.unlock(l.lock())
+	if t.dropped > 0 {
+		t.buf = append(t.buf, "... "+strconv.Itoa(t.dropped)+" entries dropped, transaction too large\n"...)
+	}
+	if len(t.buf) == 0 {
+		return nil
+	}
+	if l.owner.Load() != 0 {
+		l.internalf("recursive transaction commit suppressed")
+		return nil
+	}
+	defer l.leave(l.enter(!inert(l.cw.w)))
+
+	// A LevelWriter is passed the most severe level of the entries.
+	l.cw.level = MaxLevel
+	for _, level := range t.levels {
+		if level < l.cw.level {
+			l.cw.level = level
+		}
+	}
+	start := time.Now()
+	_, err := l.cw.Write(t.buf)
+	l.timeWrite(start)
+	l.health.record(err)
+	if err != nil {
+		l.internalf("writing transaction: %v", err)
+		return err
+	}
+	for i, level := range t.levels {
+		l.stats.add(level, int64(t.sizes[i]))
+	}
+	return nil
+}
+
+// Discard ends the transaction without writing its entries.
+func (t *Tx) Discard() {
+	t.done = true
+	t.buf, t.levels, t.sizes = nil, nil, nil
+}
+
+// add records an entry. It must be called with the mutex of the logger held,
+// directly by the method recording the entry.
+func (t *Tx) add(level int, s string) {
+	l := t.l
+	l.skip++ // skip add
+	s = l.sprintEntry(level, s)
+	l.skip--
+	if len(t.buf)+len(s) > maxTxSize {
+		t.dropped++
+		return
+	}
+	t.buf = append(t.buf, s...)
+	t.levels = append(t.levels, level)
+	t.sizes = append(t.sizes, len(s))
+}
+
+func (t *Tx) Error(v ...interface{}) {
+	defer t.l.unlock(t.l.lock())
+	if !t.done && t.l.threshold() >= LevelError {
+		t.add(LevelError, t.l.sprint(v...))
+	}
+}
+
+func (t *Tx) Errorf(format string, v ...interface{}) {
+	defer t.l.unlock(t.l.lock())
+	if !t.done && t.l.threshold() >= LevelError {
+		t.add(LevelError, t.l.sprintf(format, v...))
+	}
+}
+
+func (t *Tx) Warn(v ...interface{}) {
+	defer t.l.unlock(t.l.lock())
+	if !t.done && t.l.threshold() >= LevelWarn {
+		t.add(LevelWarn, t.l.sprint(v...))
+	}
+}
+
+func (t *Tx) Warnf(format string, v ...interface{}) {
+	defer t.l.unlock(t.l.lock())
+	if !t.done && t.l.threshold() >= LevelWarn {
+		t.add(LevelWarn, t.l.sprintf(format, v...))
+	}
+}
+
+func (t *Tx) Info(v ...interface{}) {
+	defer t.l.unlock(t.l.lock())
+	if !t.done && t.l.threshold() >= LevelInfo {
+		t.add(LevelInfo, t.l.sprint(v...))
+	}
+}
+
+func (t *Tx) Infof(format string, v ...interface{}) {
+	defer t.l.unlock(t.l.lock())
+	if !t.done && t.l.threshold() >= LevelInfo {
+		t.add(LevelInfo, t.l.sprintf(format, v...))
+	}
+}
+
+func (t *Tx) Debug(v ...interface{}) {
+	defer t.l.unlock(t.l.lock())
+	if !t.done && t.l.threshold() >= LevelDebug {
+		t.add(LevelDebug, t.l.sprint(v...))
+	}
+}
+
+func (t *Tx) Debugf(format string, v ...interface{}) {
+	defer t.l.unlock(t.l.lock())
+	if !t.done && t.l.threshold() >= LevelDebug {
+		t.add(LevelDebug, t.l.sprintf(format, v...))
+	}
+}
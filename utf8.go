@@ -0,0 +1,66 @@
+package log
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Ways of handling invalid UTF-8 in messages.
+const (
+	UTF8Replace = iota // replace invalid bytes with U+FFFD (default)
+	UTF8Escape         // escape invalid bytes as \xNN
+	UTF8Keep           // write messages as they are
+)
+
+// UTF8Mode returns how the logger handles invalid UTF-8 in messages.
+func (l *Logger) UTF8Mode() (v int) {
+	l.mu.Lock()
+	v = l.utf8
+	l.mu.Unlock()
+	return
+}
+
+// SetUTF8Mode sets how the logger handles invalid UTF-8 in messages. Use
+// UTF8Keep to log binary data deliberately.
+func (l *Logger) SetUTF8Mode(mode int) {
+	if mode < UTF8Replace || mode > UTF8Keep {
+		panic("invalid UTF-8 mode")
+	}
+	l.mu.Lock()
+	l.utf8 = mode
+	l.mu.Unlock()
+}
+
+func UTF8Mode() int {
+	return std.UTF8Mode()
+}
+
+func SetUTF8Mode(mode int) {
+	std.SetUTF8Mode(mode)
+}
+
+// sanitizeUTF8 handles invalid UTF-8 in s according to mode. It does not
+// allocate if s is valid.
+func sanitizeUTF8(s string, mode int) string {
+	if mode == UTF8Keep || utf8.ValidString(s) {
+		return s
+	}
+	if mode == UTF8Replace {
+		return strings.ToValidUTF8(s, string(utf8.RuneError))
+	}
+	const hex = "0123456789abcdef"
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && n == 1 {
+			b.WriteString(`\x`)
+			b.WriteByte(hex[s[i]>>4])
+			b.WriteByte(hex[s[i]&0xf])
+		} else {
+			b.WriteString(s[i : i+n])
+		}
+		i += n
+	}
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeUTF8(t *testing.T) {
+	for _, c := range []struct {
+		s    string
+		mode int
+		want string
+	}{
+		{"héllo", UTF8Replace, "héllo"},
+		{"a\xffb\xc3", UTF8Replace, "a�b�"},
+		{"a\xffb\xc3", UTF8Escape, `a\xffb\xc3`},
+		{"a\xffb\xc3", UTF8Keep, "a\xffb\xc3"},
+	} {
+		if got := sanitizeUTF8(c.s, c.mode); got != c.want {
+			t.Errorf("sanitizeUTF8(%q, %d) = %q, want %q", c.s, c.mode, got, c.want)
+		}
+	}
+	if n := testing.AllocsPerRun(100, func() { sanitizeUTF8("valid ☃ text", UTF8Replace) }); n != 0 {
+		t.Errorf("sanitizeUTF8 of valid text allocates %v times", n)
+	}
+}
+
+func FuzzJSONOutput(f *testing.F) {
+	f.Add([]byte("plain"), []byte("key"), UTF8Replace)
+	f.Add([]byte("bad \xff\xfe"), []byte("k\xc3"), UTF8Escape)
+	f.Add([]byte("\"quote\"\n \x00"), []byte("\\"), UTF8Replace)
+	f.Fuzz(func(t *testing.T, msg, key []byte, mode int) {
+		if mode < UTF8Replace || mode > UTF8Escape {
+			mode = UTF8Replace
+		}
+		var buf bytes.Buffer
+		l := New(&buf, "", LJSON)
+		l.SetUTF8Mode(mode)
+		l.Errorw(string(msg), string(key), string(msg), "bytes", msg)
+		out := buf.Bytes()
+		if !utf8.Valid(out) {
+			t.Fatalf("invalid UTF-8 in %q", out)
+		}
+		var e map[string]interface{}
+		if err := json.Unmarshal(out, &e); err != nil {
+			t.Fatalf("%q: %v", out, err)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package log
+
+import "log/slog"
+
+// maxLogValues is the maximum number of LogValue calls made to resolve a
+// single value, in case LogValue returns a LogValuer each time.
+const maxLogValues = 10
+
+// A LogValuer controls how a value is logged. Message arguments and field
+// values that implement LogValuer are replaced by the result of their LogValue
+// method before they are formatted. LogValue may itself return a LogValuer,
+// which is resolved in turn, up to 10 times; after that, the value is logged as
+// it is. Values that implement slog.LogValuer are resolved the same way.
+//
+// For example, a type containing secrets can log only what is safe:
+//
+//	type User struct {
+//		ID       int
+//		Email    string
+//		Password string
+//	}
+//
+//	func (u User) LogValue() interface{} {
+//		return fmt.Sprintf("user(%d)", u.ID)
+//	}
+type LogValuer interface {
+	LogValue() interface{}
+}
+
+// resolve returns the value that v resolves to. A panic in LogValue is
+// recovered and reported on the internal output, and v is then replaced by a
+// placeholder.
+func (l *Logger) resolve(v interface{}) (r interface{}) {
+	defer func() {
+		if p := recover(); p != nil {
+			r = "!PANIC(" + describePanic(p) + ")"
+			l.internalf("recovered from panic in LogValue: %s", r)
+		}
+	}()
+	for i := 0; i < maxLogValues; i++ {
+		switch lv := v.(type) {
+		case LogValuer:
+			v = lv.LogValue()
+		case slog.LogValuer:
+			v = lv.LogValue().Resolve().Any()
+		default:
+			return v
+		}
+	}
+	return v
+}
+
+// resolveAll resolves the values in v. It only copies v if one of them is a
+// LogValuer.
+func (l *Logger) resolveAll(v []interface{}) []interface{} {
+	var r []interface{}
+	for i, x := range v {
+		switch x.(type) {
+		case LogValuer, slog.LogValuer:
+			if r == nil {
+				r = append([]interface{}(nil), v...)
+			}
+			r[i] = l.resolve(x)
+		}
+	}
+	if r == nil {
+		return v
+	}
+	return r
+}
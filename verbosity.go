@@ -0,0 +1,107 @@
+package log
+
+import (
+	"flag"
+	"strconv"
+)
+
+// LevelFromVerbosity returns the log level obtained by raising base by verbose
+// steps and lowering it by quiet steps. Each step moves to the next level
+// registered with RegisterLevel, so that with a TRACE level registered at 8,
+// one step up from LevelDebug selects TRACE. The result is clamped to the range
+// LevelFatal through the highest registered level.
+func LevelFromVerbosity(base int, verbose, quiet int) int {
+	t := levels.Load()
+	level := min(max(base, LevelFatal), t.max)
+	for n := verbose - quiet; n > 0 && level < t.max; n-- {
+		level++
+		for t.label[level] == "" {
+			level++
+		}
+	}
+	for n := quiet - verbose; n > 0 && level > LevelFatal; n-- {
+		level--
+		for t.label[level] == "" {
+			level--
+		}
+	}
+	return level
+}
+
+// VerbosityFlags registers the -v, -vv and -q flags on fs. Each occurrence of
+// -v raises the level of l by one step starting from base, -vv raises it by two
+// and -q lowers it by one. The returned function must be called after fs has
+// been parsed to apply the resulting level to l.
+//
+// For example, with base LevelWarn, -v selects LevelInfo, -vv selects LevelDebug
+// and -q selects LevelError:
+//
+//	func main() {
+//		apply := log.VerbosityFlags(flag.CommandLine, log.StdLogger(), log.LevelWarn)
+//		flag.Parse()
+//		apply()
+//
+//		log.Info("shown with -v")
+//		log.Debug("shown with -vv")
+//	}
+func VerbosityFlags(fs *flag.FlagSet, l *Logger, base int) (apply func()) {
+	var verbose, quiet counter
+	fs.Var(&verbose, "v", "increase verbosity (can be repeated)")
+	fs.Var(counterAlias{&verbose, 2}, "vv", "increase verbosity by two steps")
+	fs.Var(&quiet, "q", "decrease verbosity (can be repeated)")
+	return func() {
+		l.SetLevel(LevelFromVerbosity(base, int(verbose), int(quiet)))
+	}
+}
+
+// counter is a boolean-like flag.Value that counts its occurrences. It also
+// accepts an explicit count, as in -v=2.
+type counter int
+
+func (c *counter) String() string {
+	if c == nil {
+		return "0"
+	}
+	return strconv.Itoa(int(*c))
+}
+
+func (c *counter) Set(s string) error {
+	switch s {
+	case "true":
+		*c++
+		return nil
+	case "false":
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*c += counter(n)
+	return nil
+}
+
+func (c *counter) IsBoolFlag() bool { return true }
+
+// counterAlias increments a counter by a fixed step per occurrence.
+type counterAlias struct {
+	c    *counter
+	step int
+}
+
+func (a counterAlias) String() string {
+	if a.c == nil {
+		return "0"
+	}
+	return a.c.String()
+}
+
+func (a counterAlias) Set(s string) error {
+	if s == "true" {
+		*a.c += counter(a.step)
+		return nil
+	}
+	return a.c.Set(s)
+}
+
+func (a counterAlias) IsBoolFlag() bool { return true }
@@ -0,0 +1,89 @@
+package log
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLevelFromVerbosity(t *testing.T) {
+	for _, c := range []struct {
+		base, verbose, quiet int
+		want                 int
+	}{
+		{LevelWarn, 0, 0, LevelWarn},
+		{LevelWarn, 1, 0, LevelInfo},
+		{LevelWarn, 0, 1, LevelError},
+		{LevelWarn, 1, 1, LevelWarn},
+		{LevelWarn, 0, 10, LevelFatal},
+		{LevelInfo, 10, 0, LevelDebug},
+	} {
+		if got := LevelFromVerbosity(c.base, c.verbose, c.quiet); got != c.want {
+			t.Errorf("LevelFromVerbosity(%d, %d, %d) = %d, want %d", c.base, c.verbose, c.quiet, got, c.want)
+		}
+	}
+}
+
+func TestLevelFromVerbosityRegistered(t *testing.T) {
+	defer levels.Store(levels.Load())
+	const levelTrace, levelNotice = 8, 10
+	RegisterLevel(levelTrace, "TRACE", 0)
+	RegisterLevel(levelNotice, "NOTICE", 0)
+
+	for _, c := range []struct {
+		base, verbose, quiet int
+		want                 int
+	}{
+		{LevelInfo, 1, 0, LevelDebug},
+		{LevelInfo, 2, 0, levelTrace},
+		{LevelInfo, 3, 0, levelNotice},
+		{LevelInfo, 10, 0, levelNotice},
+		{levelNotice, 0, 2, LevelDebug},
+		{levelNotice, 0, 20, LevelFatal},
+		{MaxLevel, 0, 0, levelNotice},
+	} {
+		if got := LevelFromVerbosity(c.base, c.verbose, c.quiet); got != c.want {
+			t.Errorf("LevelFromVerbosity(%d, %d, %d) = %d, want %d", c.base, c.verbose, c.quiet, got, c.want)
+		}
+	}
+}
+
+func TestVerbosityFlags(t *testing.T) {
+	for _, c := range []struct {
+		args string
+		want int
+	}{
+		{"", LevelWarn},
+		{"-v", LevelInfo},
+		{"-vv", LevelDebug},
+		{"-v -v", LevelDebug},
+		{"-v -v -v", LevelDebug},
+		{"-v=2", LevelDebug},
+		{"-q", LevelError},
+		{"-q -q -q -q -q", LevelFatal},
+		{"-v -q", LevelWarn},
+		{"-vv -q", LevelInfo},
+		{"-v=false", LevelWarn},
+	} {
+		fs := flag.NewFlagSet("cmd", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		l := New(io.Discard, "", 0)
+		apply := VerbosityFlags(fs, l, LevelWarn)
+		if err := fs.Parse(strings.Fields(c.args)); err != nil {
+			t.Errorf("%q: %v", c.args, err)
+			continue
+		}
+		apply()
+		if got := l.Level(); got != c.want {
+			t.Errorf("%q: level %d, want %d", c.args, got, c.want)
+		}
+	}
+
+	fs := flag.NewFlagSet("cmd", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	VerbosityFlags(fs, New(io.Discard, "", 0), LevelWarn)
+	if err := fs.Parse([]string{"-v=x"}); err == nil {
+		t.Error("-v=x accepted")
+	}
+}
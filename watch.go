@@ -0,0 +1,90 @@
+package log
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// WatchLevelFile sets the level of l from the contents of the file at path,
+// such as "debug", and polls the file every interval for changes. When the
+// file is modified the level is updated and the change is logged at info
+// level. Contents that are not a valid level keep the current level, and are
+// logged at warn level once for each distinct value. WatchLevelFile returns
+// an error if the file cannot be read initially. Calling stop stops watching
+// the file.
+func (l *Logger) WatchLevelFile(path string, interval time.Duration) (stop func(), err error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &levelWatcher{l: l, path: path, mtime: fi.ModTime()}
+	if err = w.load(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				w.poll()
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}, nil
+}
+
+func WatchLevelFile(path string, interval time.Duration) (stop func(), err error) {
+	return std.WatchLevelFile(path, interval)
+}
+
+// levelWatcher applies the contents of a level file to a logger.
+type levelWatcher struct {
+	l     *Logger
+	path  string
+	mtime time.Time
+	bad   string // last invalid contents reported
+}
+
+func (w *levelWatcher) poll() {
+	fi, err := os.Stat(w.path)
+	if err != nil || fi.ModTime().Equal(w.mtime) {
+		return
+	}
+	w.mtime = fi.ModTime()
+	if err = w.load(); err != nil {
+		w.l.log(LevelWarn, "reading log level file: "+err.Error())
+	}
+}
+
+func (w *levelWatcher) load() error {
+	b, err := os.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+	s := strings.TrimSpace(string(b))
+	level, err := ParseLevel(s)
+	if err != nil {
+		if s != w.bad {
+			w.bad = s
+			w.l.log(LevelWarn, "invalid log level in "+w.path+": "+err.Error())
+		}
+		return nil
+	}
+	w.bad = ""
+	if level != w.l.Level() {
+		w.l.SetLevel(level)
+		w.l.log(LevelInfo, "log level changed to "+s+" by "+w.path)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// A WebhookNotifier is an ErrorReporter that posts entries as JSON to an HTTP
+// webhook, as a last notice when a program dies:
+//
+//	logger.SetErrorReporter(log.NewWebhookNotifier(url), log.LevelPanic)
+//
+// Each entry is posted synchronously with a short timeout, so that a dead
+// network delays the exit of the program by at most about a second. Failures
+// are written to os.Stderr.
+type WebhookNotifier struct {
+	URL      string        // webhook URL
+	Service  string        // name of the service, included in the payload
+	Slack    bool          // post a Slack-compatible {"text": ...} payload
+	MinLevel int           // minimum level of entries to post; LevelPanic by default
+	Timeout  time.Duration // timeout of each post; one second by default
+	Client   *http.Client  // client to post with; http.DefaultClient by default
+}
+
+// NewWebhookNotifier returns a WebhookNotifier posting fatal and panic entries
+// to url.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:      url,
+		MinLevel: LevelPanic,
+		Timeout:  time.Second,
+	}
+}
+
+// webhookPayload is the generic payload posted by a WebhookNotifier.
+type webhookPayload struct {
+	Host    string `json:"host"`
+	Service string `json:"service,omitempty"`
+	Level   string `json:"level"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+	Stack   string `json:"stack,omitempty"`
+}
+
+// Report posts e to the webhook, if its level is at or above n.MinLevel.
+func (n *WebhookNotifier) Report(e *Entry) {
+	if e.Level > n.MinLevel {
+		return
+	}
+	host, _ := os.Hostname()
+	level := levelName(e.Level)
+	var payload interface{} = webhookPayload{
+		Host:    host,
+		Service: n.Service,
+		Level:   level,
+		Code:    e.Code,
+		Message: e.Message,
+		Stack:   string(e.Stack),
+	}
+	if n.Slack {
+		text := fmt.Sprintf("*%s* on %s", level, host)
+		if n.Service != "" {
+			text += " (" + n.Service + ")"
+		}
+		text += ": "
+		if e.Code != "" {
+			text += "[" + e.Code + "] "
+		}
+		text += e.Message
+		if len(e.Stack) > 0 {
+			text += "\n```\n" + string(e.Stack) + "```"
+		}
+		payload = map[string]string{"text": text}
+	}
+	if err := n.post(payload); err != nil {
+		fmt.Fprintf(os.Stderr, "log: webhook notification failed: %v\n", err)
+	}
+}
+
+func (n *WebhookNotifier) post(payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	c := *client
+	c.Timeout = timeout
+	resp, err := c.Post(n.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s", n.URL, resp.Status)
+	}
+	return nil
+}
+
+// Flush does nothing, since entries are posted synchronously.
+func (n *WebhookNotifier) Flush(timeout time.Duration) {}
@@ -0,0 +1,93 @@
+package log
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	posted := make(chan map[string]string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Error(err)
+		}
+		posted <- p
+	}))
+	defer srv.Close()
+
+	l := New(io.Discard, "", 0)
+	n := NewWebhookNotifier(srv.URL)
+	n.Service = "api"
+	l.SetErrorReporter(n, LevelPanic)
+	// The notification is posted before the program exits.
+	var atExit int
+	prev := SetExitFunc(func(int) { atExit = len(posted) })
+	defer SetExitFunc(prev)
+
+	l.Error("not posted")
+	l.Fatalc("DB1", "database gone")
+	if atExit != 1 {
+		t.Fatalf("%d notifications posted before exit, want 1", atExit)
+	}
+	host, _ := os.Hostname()
+	p := <-posted
+	if p["host"] != host || p["service"] != "api" || p["level"] != "FATAL" || p["code"] != "DB1" || p["message"] != "database gone" {
+		t.Errorf("payload = %v", p)
+	}
+
+	n.Slack = true
+	func() {
+		defer func() { recover() }()
+		l.Panic("boom")
+	}()
+	l.rep.flush()
+	select {
+	case p := <-posted:
+		if want := "*PANIC* on " + host + " (api): boom"; p["text"] != want {
+			t.Errorf("Slack payload = %v, want text %q", p, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("panic entry not posted")
+	}
+}
+
+func TestWebhookNotifierTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	stderr, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(f *os.File) { os.Stderr = f }(os.Stderr)
+	os.Stderr = stderr
+
+	l := New(io.Discard, "", 0)
+	n := NewWebhookNotifier(srv.URL)
+	n.Timeout = 50 * time.Millisecond
+	l.SetErrorReporter(n, LevelFatal)
+	exited := false
+	prev := SetExitFunc(func(int) { exited = true })
+	defer SetExitFunc(prev)
+
+	start := time.Now()
+	l.Fatal("network down")
+	if d := time.Since(start); !exited || d > time.Second {
+		t.Errorf("exited %t after %v with a dead webhook", exited, d)
+	}
+	b, _ := os.ReadFile(stderr.Name())
+	if !strings.HasPrefix(string(b), "log: webhook notification failed: ") {
+		t.Errorf("stderr = %q", b)
+	}
+}
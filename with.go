@@ -0,0 +1,160 @@
+package log
+
+import "fmt"
+
+// MissingValue is the value given to the last key passed to With or one of
+// the *w methods, like Infow, if it has no value.
+const MissingValue = "MISSING_VALUE"
+
+// With returns a Logger that writes through l, and appends the given key/value
+// pairs to the message of every entry it logs, after the global and dynamic
+// fields of l. In text output they are written as key=value pairs; with LJSON
+// they become fields of the JSON object. The returned Logger shares the output,
+// level, flags and other settings of l; calling With on it adds to its pairs.
+//
+// The arguments alternate between keys and values. Keys that are not strings
+// are formatted with fmt.Sprint. A Field argument counts as a key/value pair
+// by itself. If the last key has no value, it gets MissingValue.
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+	d := &Logger{
+		logger: l.logger,
+		every:  l.every,
+		comp:   l.comp,
+		with:   appendPairs(l.with[:len(l.with):len(l.with)], keysAndValues),
+	}
+	d.depth.Store(l.depth.Load())
+	return d
+}
+
+func With(keysAndValues ...interface{}) *Logger {
+	return std.With(keysAndValues...)
+}
+
+// appendPairs appends the key/value pairs in kv to f, as described for With.
+func appendPairs(f []Field, kv []interface{}) []Field {
+	for i := 0; i < len(kv); i++ {
+		if field, ok := kv[i].(Field); ok {
+			f = append(f, field)
+			continue
+		}
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		var value interface{} = MissingValue
+		if i+1 < len(kv) {
+			i++
+			value = kv[i]
+		}
+		f = append(f, Field{key, value})
+	}
+	return f
+}
+
+// Fatalw logs msg with the given key/value pairs, as described for With, and
+// exits like Fatal.
+func (l *Logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	locked := l.lock()
+	if l.enabled(LevelFatal) {
+		l.extra.fields = appendPairs(l.extra.fields, keysAndValues)
+		l.format(LevelFatal, msg)
+	}
+	l.fatalExit(locked, 1)
+}
+
+// Panicw is like Fatalw, but panics with msg like Panic.
+func (l *Logger) Panicw(msg string, keysAndValues ...interface{}) {
+	defer l.unlock(l.lock())
+	if l.enabled(LevelPanic) {
+		l.extra.fields = appendPairs(l.extra.fields, keysAndValues)
+		l.format(LevelPanic, msg)
+	}
+	panic(msg)
+}
+
+// Errorw is like Fatalw, but logs at error level.
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	if l.suppressed(LevelError) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelError) {
+		l.extra.fields = appendPairs(l.extra.fields, keysAndValues)
+		l.format(LevelError, msg)
+	}
+}
+
+// Warnw is like Fatalw, but logs at warning level.
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	if l.suppressed(LevelWarn) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelWarn) {
+		l.extra.fields = appendPairs(l.extra.fields, keysAndValues)
+		l.format(LevelWarn, msg)
+	}
+}
+
+// Infow is like Fatalw, but logs at info level.
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	if l.suppressed(LevelInfo) {
+		return
+	}
+	defer l.unlock(l.lock())
+	if l.enabled(LevelInfo) {
+		l.extra.fields = appendPairs(l.extra.fields, keysAndValues)
+		l.format(LevelInfo, msg)
+	}
+}
+
+func Fatalw(msg string, keysAndValues ...interface{}) {
+	locked := std.lock()
+	if std.enabled(LevelFatal) {
+		std.extra.fields = appendPairs(std.extra.fields, keysAndValues)
+		std.format(LevelFatal, msg)
+	}
+	std.fatalExit(locked, 1)
+}
+
+func Panicw(msg string, keysAndValues ...interface{}) {
+	defer std.unlock(std.lock())
+	if std.enabled(LevelPanic) {
+		std.extra.fields = appendPairs(std.extra.fields, keysAndValues)
+		std.format(LevelPanic, msg)
+	}
+	panic(msg)
+}
+
+func Errorw(msg string, keysAndValues ...interface{}) {
+	if std.suppressed(LevelError) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelError) {
+		std.extra.fields = appendPairs(std.extra.fields, keysAndValues)
+		std.format(LevelError, msg)
+	}
+}
+
+func Warnw(msg string, keysAndValues ...interface{}) {
+	if std.suppressed(LevelWarn) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelWarn) {
+		std.extra.fields = appendPairs(std.extra.fields, keysAndValues)
+		std.format(LevelWarn, msg)
+	}
+}
+
+func Infow(msg string, keysAndValues ...interface{}) {
+	if std.suppressed(LevelInfo) {
+		return
+	}
+	defer std.unlock(std.lock())
+	if std.enabled(LevelInfo) {
+		std.extra.fields = appendPairs(std.extra.fields, keysAndValues)
+		std.format(LevelInfo, msg)
+	}
+}
@@ -0,0 +1,47 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWith(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", Llabel)
+	l.SetLevel(LevelDebug)
+	w := l.With("a", 1).With("b", "x y", Field{"c", true})
+
+	w.Infow("hi", "d", 2, "odd")
+	l.Info("plain")
+	w.Every(time.Hour).Warn("ev")
+	want := "[INFO ] hi a=1 b=\"x y\" c=true d=2 odd=MISSING_VALUE\n" +
+		"[INFO ] plain\n" +
+		"[WARN ] ev a=1 b=\"x y\" c=true\n"
+	if got := buf.String(); got != want {
+		t.Errorf("text output = %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	l.SetFlags(LJSON)
+	w.Debugw("j", 3, 4)
+	if got, want := buf.String(), `{"level":"debug","msg":"j","a":1,"b":"x y","c":true,"3":4}`+"\n"; got != want {
+		t.Errorf("JSON output = %q, want %q", got, want)
+	}
+}
+
+func TestPanicwDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	l.SetLevel(LevelFatal)
+
+	defer func() {
+		if p := recover(); p != "msg" {
+			t.Errorf("Panicw panicked with %v, want msg", p)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("Panicw wrote %q with panic entries disabled", buf.String())
+		}
+	}()
+	l.Panicw("msg", "k", 1)
+}
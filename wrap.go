@@ -0,0 +1,132 @@
+package log
+
+import (
+	"io"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// SoftWrap reports whether soft wrapping is enabled, see SetSoftWrap.
+func (l *Logger) SoftWrap() (v bool) {
+	defer l.unlock(l.lock())
+	return l.wrap
+}
+
+// SetSoftWrap enables or disables soft wrapping of messages written to a
+// terminal. Lines that are wider than the terminal are then wrapped at word
+// boundaries, and the continuation lines are indented to the column at which
+// the message starts. Color sequences do not count toward the width. The width
+// of the terminal is queried for each entry, so that resizing it takes effect
+// immediately. Output that is not a terminal is never wrapped.
+func (l *Logger) SetSoftWrap(enable bool) {
+	defer l.unlock(l.lock())
+	l.wrap = enable
+}
+
+func SoftWrap() bool {
+	return std.SoftWrap()
+}
+
+func SetSoftWrap(enable bool) {
+	std.SetSoftWrap(enable)
+}
+
+// softWrap wraps the lines of s, which follows the header, to the width of the
+// terminal. It must be called by render.
+func (l *Logger) softWrap(s, nl string) string {
+	width := termWidth(l.out)
+	if width <= 0 {
+		return s
+	}
+	start := l.headerWidth()
+	indent := start
+	if l.flag&Llabel != 0 {
+		indent += len("[] ") + len(levelLabel(0))
+	}
+	if indent >= width/2 {
+		// Not enough room to indent the continuation lines.
+		indent = 0
+	}
+	pad := strings.Repeat(" ", indent)
+
+	var b strings.Builder
+	col := start
+	for i, line := range strings.Split(s, nl) {
+		if i > 0 {
+			b.WriteString(nl)
+			col = 0
+		}
+		for j, word := range strings.Split(line, " ") {
+			w := visibleWidth(word)
+			if j > 0 {
+				if col+1+w > width && col > indent {
+					b.WriteString(nl)
+					b.WriteString(pad)
+					col = indent
+				} else {
+					b.WriteByte(' ')
+					col++
+				}
+			}
+			b.WriteString(word)
+			col += w
+		}
+	}
+	return b.String()
+}
+
+// headerWidth returns the width of the header that the standard logger writes
+// before the message. It must be called by softWrap.
+func (l *Logger) headerWidth() int {
+	n := len(l.Prefix())
+	if l.flag&Ldate != 0 {
+		n += len("2006/01/02 ")
+	}
+	if l.flag&(Ltime|Lmicroseconds) != 0 {
+		n += len("15:04:05 ")
+		if l.flag&Lmicroseconds != 0 {
+			n += len(".000000")
+		}
+	}
+	if l.flag&(Lshortfile|Llongfile) != 0 {
+		// Skip headerWidth, softWrap, render, format and the logging method.
+		_, file, line, ok := runtime.Caller(5 + l.skip)
+		if !ok {
+			file, line = "???", 0
+		}
+		if l.flag&Lshortfile != 0 {
+			file = filepath.Base(file)
+		}
+		n += len(file) + len(":") + len(strconv.Itoa(line)) + len(": ")
+	}
+	return n
+}
+
+// termWidth returns the width of the terminal that w writes to, or 0.
+func termWidth(w io.Writer) int {
+	file, ok := w.(interface {
+		Fd() uintptr
+	})
+	if !ok {
+		return 0
+	}
+	width, _, err := terminal.GetSize(int(file.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// visibleWidth returns the number of characters in s, not counting color
+// sequences.
+func visibleWidth(s string) int {
+	if strings.IndexByte(s, '\033') >= 0 {
+		s = ansiSeq.ReplaceAllString(s, "")
+	}
+	return utf8.RuneCountInString(s)
+}